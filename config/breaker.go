@@ -0,0 +1,38 @@
+package config
+
+import (
+	"time"
+
+	"github.com/Reza1878/goesclearning/user-service/helper/breaker"
+)
+
+type CircuitBreakerConfig struct {
+	Enabled bool
+	Breaker breaker.Config
+
+	// StaleFallback answers list calls from the last good response while
+	// the product service is unavailable.
+	StaleFallback   bool
+	StaleTTL        time.Duration
+	StaleMaxEntries int
+}
+
+func loadCircuitBreaker(l *loader) CircuitBreakerConfig {
+	cfg := CircuitBreakerConfig{
+		Enabled: l.boolean("RPC_BREAKER_ENABLED"),
+		Breaker: breaker.Config{
+			FailureThreshold: l.integer("RPC_BREAKER_FAILURE_THRESHOLD", 1),
+			OpenTimeout:      l.duration("RPC_BREAKER_OPEN_TIMEOUT"),
+			HalfOpenProbes:   l.integer("RPC_BREAKER_HALF_OPEN_PROBES", 1),
+		},
+		StaleFallback:   l.boolean("RPC_STALE_FALLBACK"),
+		StaleTTL:        l.duration("RPC_STALE_TTL"),
+		StaleMaxEntries: l.integer("RPC_STALE_MAX_ENTRIES", 1),
+	}
+
+	if cfg.Enabled && cfg.Breaker.OpenTimeout <= 0 {
+		l.fail("RPC_BREAKER_OPEN_TIMEOUT", "must be greater than zero")
+	}
+
+	return cfg
+}
@@ -1,54 +1,273 @@
 package config
 
 import (
+	"errors"
 	"fmt"
+	"os"
+	"strings"
 
+	"github.com/Reza1878/goesclearning/user-service/helper/logger"
+	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
 )
 
 type Config struct {
-	Port     string
-	Grpc     RPCConfig
-	Postgres PostgreSQLConfig
-	Redis    RedisConfig
+	Port        string
+	FakeProduct bool
+	BaseURLPath string
+	ErrorFormat string
+	// TrustedProxies are the proxies allowed to set the client IP through
+	// X-Forwarded-For. Empty means the peer address is the client.
+	TrustedProxies []string
+	JWT            JWTConfig
+	EmailFolding   string
+	Grpc           RPCConfig
+	Breaker        CircuitBreakerConfig
+	Cache          CacheConfig
+	RateLimit      RateLimitConfig
+	LoginEvents    LoginEventsConfig
+	Outbox         OutboxConfig
+	Postgres       PostgreSQLConfig
+	Redis          RedisConfig
+	Log            logger.Config
+	CORS           CORSConfig
 }
 
-func Load() (*Config, error) {
-	viper.New()
-	viper.SetConfigName("config")
-	viper.AddConfigPath(".")
-	viper.AutomaticEnv()
-	viper.SetConfigType("yaml")
+type JWTConfig struct {
+	Secret string
+}
+
+// ErrHelp is returned by Load when the usage text was requested.
+var ErrHelp = pflag.ErrHelp
+
+type setting struct {
+	key    string
+	def    string
+	usage  string
+	secret bool
+	// boolean settings can be switched on with a bare flag, e.g.
+	// --fake-product.
+	boolean bool
+}
+
+// settings lists every flat configuration key. Each one can come from the
+// config file, the environment (same name) or a flag (lowercase, dashes).
+// Secrets may also be read from the file named by <KEY>_FILE.
+var settings = []setting{
+	{key: "PORT", def: "8080", usage: "HTTP listen port"},
+	{key: "BASE_URL_PATH", def: "/", usage: "path prefix for every route"},
+	{key: "ERROR_FORMAT", def: "json", usage: "error body format: json or problem"},
+	{key: "JWT_SECRET", usage: "HMAC key used to sign access and refresh tokens", secret: true},
+	{key: "TRUSTED_PROXIES", usage: "comma separated proxy IPs or CIDRs whose X-Forwarded-For is believed; empty trusts none"},
+	{key: "EMAIL_LOCAL_FOLDING", def: "lower", usage: "email local part folding: none, lower or subaddress (lower and drop +tag)"},
+
+	{key: "FAKE_PRODUCT", def: "false", usage: "serve products from an in-memory fake instead of the product service", boolean: true},
+	{key: "RPC_PORT", def: "50051", usage: "product service gRPC port on localhost, used when RPC_TARGET is empty"},
+	{key: "RPC_TARGET", usage: "product service gRPC target URI, e.g. dns:///product:50051"},
+	{key: "RPC_LOAD_BALANCING", def: "round_robin", usage: "round_robin or pick_first"},
+	{key: "RPC_TLS", def: "false", usage: "use TLS towards the product service", boolean: true},
+	{key: "RPC_TLS_CA_FILE", usage: "CA bundle used to verify the product service"},
+	{key: "RPC_TLS_CERT_FILE", usage: "client certificate for mutual TLS"},
+	{key: "RPC_TLS_KEY_FILE", usage: "client key for mutual TLS"},
+	{key: "RPC_TLS_SERVER_NAME", usage: "override the TLS server name"},
+	{key: "RPC_KEEPALIVE_TIME", def: "30s", usage: "ping the server after this much inactivity"},
+	{key: "RPC_KEEPALIVE_TIMEOUT", def: "10s", usage: "wait this long for a ping ack"},
+	{key: "RPC_TIMEOUT", def: "5s", usage: "default per-call deadline"},
+	{key: "RPC_METHOD_TIMEOUTS", usage: "per-method deadlines, e.g. ListProduct=2s,InsertProduct=5s"},
+	{key: "RPC_RETRY_METHODS", def: "ListProduct", usage: "comma separated idempotent methods retried on UNAVAILABLE"},
+	{key: "RPC_RETRY_MAX_ATTEMPTS", def: "3", usage: "attempts per retried call, including the first"},
+	{key: "RPC_RETRY_INITIAL_BACKOFF", def: "100ms", usage: "first retry backoff"},
+	{key: "RPC_RETRY_MAX_BACKOFF", def: "1s", usage: "maximum retry backoff"},
+	{key: "RPC_SERVICE_CONFIG", usage: "raw gRPC service config JSON, replaces the generated one"},
+	{key: "RPC_BREAKER_ENABLED", def: "true", usage: "guard product service calls with a circuit breaker", boolean: true},
+	{key: "RPC_BREAKER_FAILURE_THRESHOLD", def: "5", usage: "consecutive failures that open the breaker"},
+	{key: "RPC_BREAKER_OPEN_TIMEOUT", def: "30s", usage: "how long the breaker stays open before probing"},
+	{key: "RPC_BREAKER_HALF_OPEN_PROBES", def: "1", usage: "probe calls (and successes needed) while half-open"},
+	{key: "RPC_STALE_FALLBACK", def: "false", usage: "serve the last good product list while the product service is down", boolean: true},
+	{key: "RPC_STALE_TTL", def: "10m", usage: "maximum age of a stale product list"},
+	{key: "RPC_STALE_MAX_ENTRIES", def: "256", usage: "product lists kept for the stale fallback"},
+
+	{key: "DB_HOST", def: "localhost", usage: "PostgreSQL host"},
+	{key: "DB_PORT", def: "5432", usage: "PostgreSQL port"},
+	{key: "DB_USERNAME", def: "postgres", usage: "PostgreSQL user"},
+	{key: "DB_PASSWORD", usage: "PostgreSQL password", secret: true},
+	{key: "DB_NAME", def: "user_db", usage: "PostgreSQL database"},
+	{key: "DB_MAX_OPEN_CONN", def: "10", usage: "maximum open connections"},
+	{key: "DB_MAX_IDLE_CONN", def: "5", usage: "maximum idle connections"},
+	{key: "DB_MAX_LIFE_TIME", def: "5m", usage: "maximum connection lifetime (bare numbers are minutes)"},
+
+	{key: "REDIS_ADDRESS", def: "localhost:6379", usage: "Redis host:port"},
+	{key: "REDIS_PASSWORD", usage: "Redis password", secret: true},
+	{key: "REDIS_DB", def: "0", usage: "Redis database number"},
+
+	{key: "CACHE_BACKEND", def: "redis", usage: "redis, or memory for a single instance without Redis"},
+	{key: "CACHE_MEMORY_MAX_ENTRIES", def: "10000", usage: "keys kept by the memory cache backend before evicting"},
+	{key: "PRODUCT_CACHE_ENABLED", def: "true", usage: "cache product lists", boolean: true},
+	{key: "PRODUCT_CACHE_TTL", def: "1m", usage: "how long a cached product list is served"},
+	{key: "USER_CACHE_ENABLED", def: "true", usage: "cache user lookups by id and email", boolean: true},
+	{key: "USER_CACHE_TTL", def: "5m", usage: "how long a cached user is served"},
+	{key: "USER_CACHE_NEGATIVE_TTL", def: "30s", usage: "how long an unknown id or email is remembered"},
+
+	{key: "USERNAME_CHECK_RATE_LIMIT", def: "30", usage: "username availability checks allowed per client IP and window"},
+	{key: "USERNAME_CHECK_RATE_WINDOW", def: "1m", usage: "window of the username availability rate limit"},
+
+	{key: "LOGIN_EVENTS_RETENTION", def: "2160h", usage: "how long login events are kept, 0 keeps them forever"},
+	{key: "LOGIN_EVENTS_PRUNE_INTERVAL", def: "1h", usage: "how often expired login events are deleted"},
+
+	{key: "OUTBOX_RELAY_ENABLED", def: "true", usage: "publish domain events from the outbox table", boolean: true},
+	{key: "OUTBOX_PUBLISHER", def: "log", usage: "log, nats or kafka (through a Kafka REST proxy)"},
+	{key: "OUTBOX_NATS_URL", def: "nats://localhost:4222", usage: "NATS server for the nats publisher"},
+	{key: "OUTBOX_KAFKA_REST_URL", def: "http://localhost:8082", usage: "Kafka REST proxy for the kafka publisher"},
+	{key: "OUTBOX_PUBLISH_TIMEOUT", def: "5s", usage: "deadline for publishing one event"},
+	{key: "OUTBOX_BATCH_SIZE", def: "100", usage: "events claimed per relay round"},
+	{key: "OUTBOX_POLL_INTERVAL", def: "1s", usage: "how often the relay looks for new events"},
+	{key: "OUTBOX_LEASE", def: "30s", usage: "how long a claimed event is hidden from other relays"},
+	{key: "OUTBOX_MAX_BACKOFF", def: "5m", usage: "longest retry delay of a failing event"},
+	{key: "OUTBOX_RETENTION", def: "168h", usage: "how long published events are kept, 0 keeps them forever"},
+
+	{key: "LOG_LEVEL", def: "info", usage: "debug, info, warn or error"},
+	{key: "LOG_FORMAT", def: "json", usage: "json or text"},
 
-	err := viper.ReadInConfig()
+	{key: "CORS_ALLOWED_ORIGINS", usage: "comma separated origins, *.domain patterns allowed"},
+	{key: "CORS_ALLOWED_METHODS", usage: "comma separated methods"},
+	{key: "CORS_ALLOWED_HEADERS", usage: "comma separated request headers"},
+	{key: "CORS_EXPOSED_HEADERS", usage: "comma separated response headers"},
+	{key: "CORS_MAX_AGE", usage: "preflight cache duration"},
+	{key: "CORS_ALLOW_CREDENTIALS", def: "false", usage: "allow credentialed requests", boolean: true},
+}
+
+// Load merges defaults, an optional config file, the environment and the
+// given command line flags, in that order of precedence, and validates
+// the result. Every problem found is reported in one error.
+func Load(args []string) (*Config, error) {
+	v, err := newViper(args)
 	if err != nil {
-		return nil, fmt.Errorf("failed read config file: %v", err)
+		return nil, err
 	}
 
+	l := &loader{v: v}
+	l.readSecretFiles()
+
 	cfg := &Config{
-		Port: viper.GetString("PORT"),
+		Port:        l.port("PORT"),
+		FakeProduct: l.boolean("FAKE_PRODUCT"),
+		BaseURLPath: v.GetString("BASE_URL_PATH"),
+		ErrorFormat: l.oneOf("ERROR_FORMAT", "json", "problem"),
 
-		Grpc: RPCConfig{
-			Port: viper.GetString("RPC_PORT"),
+		TrustedProxies: l.networks("TRUSTED_PROXIES"),
+
+		JWT: JWTConfig{
+			Secret: l.required("JWT_SECRET"),
 		},
+		EmailFolding: l.oneOf("EMAIL_LOCAL_FOLDING", "none", "lower", "subaddress"),
+
+		Grpc:    loadRPC(l),
+		Breaker: loadCircuitBreaker(l),
 
 		Postgres: PostgreSQLConfig{
-			DbHost:        viper.GetString("DB_HOST"),
-			DbPort:        viper.GetString("DB_PORT"),
-			DbUsername:    viper.GetString("DB_USERNAME"),
-			DbPassword:    viper.GetString("DB_PASSWORD"),
-			DbName:        viper.GetString("DB_NAME"),
-			DbMaxOpenConn: viper.GetInt("DB_MAX_OPEN_CONN"),
-			DbMaxIdleConn: viper.GetInt("DB_MAX_IDLE_CONN"),
-			DbMaxLifeTime: viper.GetDuration("DB_MAX_LIFE_TIME"),
+			DbHost:        l.required("DB_HOST"),
+			DbPort:        l.port("DB_PORT"),
+			DbUsername:    l.required("DB_USERNAME"),
+			DbPassword:    v.GetString("DB_PASSWORD"),
+			DbName:        l.required("DB_NAME"),
+			DbMaxOpenConn: l.integer("DB_MAX_OPEN_CONN", 0),
+			DbMaxIdleConn: l.integer("DB_MAX_IDLE_CONN", 0),
+			DbMaxLifeTime: l.minutes("DB_MAX_LIFE_TIME"),
 		},
 
 		Redis: RedisConfig{
-			Address:  viper.GetString("REDIS_ADDRESS"),
-			Password: viper.GetString("REDIS_PASSWORD"),
-			DB:       viper.GetInt("REDIS_DB"),
+			Address:  l.required("REDIS_ADDRESS"),
+			Password: v.GetString("REDIS_PASSWORD"),
+			DB:       l.integer("REDIS_DB", 0),
+		},
+
+		Cache: CacheConfig{
+			Backend:          l.oneOf("CACHE_BACKEND", "redis", "memory"),
+			MemoryMaxEntries: l.integer("CACHE_MEMORY_MAX_ENTRIES", 1),
+
+			ProductEnabled: l.boolean("PRODUCT_CACHE_ENABLED"),
+			ProductTTL:     l.duration("PRODUCT_CACHE_TTL"),
+
+			UserEnabled:     l.boolean("USER_CACHE_ENABLED"),
+			UserTTL:         l.duration("USER_CACHE_TTL"),
+			UserNegativeTTL: l.duration("USER_CACHE_NEGATIVE_TTL"),
 		},
+
+		RateLimit:   loadRateLimit(l),
+		LoginEvents: loadLoginEvents(l),
+		Outbox:      loadOutbox(l),
+
+		CORS: loadCORS(l),
+
+		Log: logger.Config{
+			Level:  l.oneOf("LOG_LEVEL", "debug", "info", "warn", "error"),
+			Format: l.oneOf("LOG_FORMAT", "json", "text"),
+		},
+	}
+
+	if len(l.problems) > 0 {
+		return nil, &ValidationError{Problems: l.problems}
 	}
 
 	return cfg, nil
 }
+
+func newViper(args []string) (*viper.Viper, error) {
+	v := viper.New()
+	v.AutomaticEnv()
+
+	flags := pflag.NewFlagSet("user-service", pflag.ContinueOnError)
+	configFile := flags.String("config", "", "path to a YAML config file (default ./config.yaml if present)")
+
+	for _, s := range settings {
+		if s.def != "" {
+			v.SetDefault(s.key, s.def)
+		}
+
+		name := flagName(s.key)
+		flags.String(name, "", s.usage)
+		if s.boolean {
+			flags.Lookup(name).NoOptDefVal = "true"
+		}
+		if err := v.BindPFlag(s.key, flags.Lookup(name)); err != nil {
+			return nil, fmt.Errorf("failed to bind flag --%s: %w", name, err)
+		}
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if *configFile == "" {
+		*configFile = os.Getenv("CONFIG_FILE")
+	}
+
+	if *configFile != "" {
+		v.SetConfigFile(*configFile)
+	} else {
+		v.SetConfigName("config")
+		v.SetConfigType("yaml")
+		v.AddConfigPath(".")
+	}
+
+	if err := v.ReadInConfig(); err != nil {
+		var notFound viper.ConfigFileNotFoundError
+		if *configFile != "" || !errors.As(err, &notFound) {
+			return nil, fmt.Errorf("failed read config file: %w", err)
+		}
+	}
+
+	return v, nil
+}
+
+func flagName(key string) string {
+	return strings.ReplaceAll(strings.ToLower(key), "_", "-")
+}
+
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid configuration:\n  - " + strings.Join(e.Problems, "\n  - ")
+}
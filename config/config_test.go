@@ -0,0 +1,155 @@
+package config
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestLoadBooleanFlags(t *testing.T) {
+	tests := []struct {
+		name        string
+		args        []string
+		fakeProduct bool
+		tls         bool
+	}{
+		{"defaults", nil, false, false},
+		{"bare flag", []string{"--fake-product"}, true, false},
+		{"explicit true", []string{"--fake-product=true"}, true, false},
+		{"explicit false", []string{"--fake-product=false"}, false, false},
+		{"several bare flags", []string{"--fake-product", "--rpc-tls"}, true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("JWT_SECRET", "test-secret")
+
+			cfg, err := Load(tt.args)
+			if err != nil {
+				t.Fatalf("Load(%v): %v", tt.args, err)
+			}
+			if cfg.FakeProduct != tt.fakeProduct {
+				t.Errorf("FakeProduct = %v, want %v", cfg.FakeProduct, tt.fakeProduct)
+			}
+			if cfg.Grpc.TLS.Enabled != tt.tls {
+				t.Errorf("TLS = %v, want %v", cfg.Grpc.TLS.Enabled, tt.tls)
+			}
+		})
+	}
+}
+
+func TestLoadRejectsAnyOriginWithCredentials(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-secret")
+
+	_, err := Load([]string{"--cors-allowed-origins=*", "--cors-allow-credentials"})
+
+	var validation *ValidationError
+	if !errors.As(err, &validation) {
+		t.Fatalf("got %v, want a validation error", err)
+	}
+	if !strings.Contains(validation.Error(), "CORS_ALLOWED_ORIGINS") {
+		t.Fatalf("error does not name CORS_ALLOWED_ORIGINS: %v", validation)
+	}
+}
+
+func TestLoadTrustedProxies(t *testing.T) {
+	tests := []struct {
+		value string
+		want  []string
+		ok    bool
+	}{
+		{"", nil, true},
+		{"10.0.0.1, 192.168.0.0/16,::1", []string{"10.0.0.1", "192.168.0.0/16", "::1"}, true},
+		{"proxy.internal", nil, false},
+		{"10.0.0.0/33", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			t.Setenv("JWT_SECRET", "test-secret")
+			t.Setenv("TRUSTED_PROXIES", tt.value)
+
+			cfg, err := Load(nil)
+			if !tt.ok {
+				if err == nil {
+					t.Fatalf("Load accepted TRUSTED_PROXIES=%q", tt.value)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+			if strings.Join(cfg.TrustedProxies, ",") != strings.Join(tt.want, ",") {
+				t.Fatalf("TrustedProxies = %v, want %v", cfg.TrustedProxies, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadRetryBackoffs(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr string
+	}{
+		{"defaults", nil, ""},
+		{"zero initial", []string{"--rpc-retry-initial-backoff=0"}, "RPC_RETRY_INITIAL_BACKOFF"},
+		{"zero max", []string{"--rpc-retry-max-backoff=0s"}, "RPC_RETRY_MAX_BACKOFF"},
+		{"initial above max", []string{"--rpc-retry-initial-backoff=2s", "--rpc-retry-max-backoff=1s"}, "RPC_RETRY_INITIAL_BACKOFF"},
+		{"retries disabled", []string{"--rpc-retry-max-attempts=1", "--rpc-retry-initial-backoff=0"}, ""},
+		{"no retried methods", []string{"--rpc-retry-methods=", "--rpc-retry-max-backoff=0"}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("JWT_SECRET", "test-secret")
+
+			_, err := Load(tt.args)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Load(%v): %v", tt.args, err)
+				}
+				return
+			}
+
+			var validation *ValidationError
+			if !errors.As(err, &validation) || !strings.Contains(validation.Error(), tt.wantErr) {
+				t.Fatalf("Load(%v): got %v, want a validation error naming %s", tt.args, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadNATSURL(t *testing.T) {
+	tests := []struct {
+		publisher string
+		url       string
+		ok        bool
+	}{
+		{"nats", "nats://localhost:4222", true},
+		{"nats", "tls://localhost:4222", false},
+		{"nats", "http://localhost:4222", false},
+		{"log", "tls://localhost:4222", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.publisher+" "+tt.url, func(t *testing.T) {
+			t.Setenv("JWT_SECRET", "test-secret")
+			t.Setenv("OUTBOX_PUBLISHER", tt.publisher)
+			t.Setenv("OUTBOX_NATS_URL", tt.url)
+
+			_, err := Load(nil)
+			if tt.ok {
+				if err != nil {
+					t.Fatalf("Load: %v", err)
+				}
+				return
+			}
+
+			var validation *ValidationError
+			if !errors.As(err, &validation) || !strings.Contains(validation.Error(), "OUTBOX_NATS_URL") {
+				t.Fatalf("got %v, want a validation error naming OUTBOX_NATS_URL", err)
+			}
+		})
+	}
+}
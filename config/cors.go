@@ -0,0 +1,105 @@
+package config
+
+import (
+	"strings"
+	"time"
+)
+
+type CORSPolicy struct {
+	AllowedOrigins   []string      `mapstructure:"allowed_origins"`
+	AllowedMethods   []string      `mapstructure:"allowed_methods"`
+	AllowedHeaders   []string      `mapstructure:"allowed_headers"`
+	ExposedHeaders   []string      `mapstructure:"exposed_headers"`
+	MaxAge           time.Duration `mapstructure:"max_age"`
+	AllowCredentials bool          `mapstructure:"allow_credentials"`
+}
+
+type CORSConfig struct {
+	Default CORSPolicy
+	// Groups overrides the default policy for a route group, keyed by the
+	// group path relative to BASE_URL_PATH (e.g. "product").
+	Groups map[string]CORSPolicy
+}
+
+var (
+	defaultCORSMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}
+	defaultCORSHeaders = []string{"X-Requested-With", "Content-Type", "Origin", "Authorization", "Accept", "Accept-Language", "X-Request-ID", "Idempotency-Key"}
+	defaultCORSExposed = []string{"Content-Length", "X-Request-ID", "Retry-After", "X-RateLimit-Limit", "X-RateLimit-Remaining"}
+)
+
+const defaultCORSMaxAge = 24 * time.Hour
+
+func loadCORS(l *loader) CORSConfig {
+	cfg := CORSConfig{
+		Default: CORSPolicy{
+			AllowedOrigins:   splitList(l.v.GetString("CORS_ALLOWED_ORIGINS")),
+			AllowedMethods:   splitList(l.v.GetString("CORS_ALLOWED_METHODS")),
+			AllowedHeaders:   splitList(l.v.GetString("CORS_ALLOWED_HEADERS")),
+			ExposedHeaders:   splitList(l.v.GetString("CORS_EXPOSED_HEADERS")),
+			MaxAge:           l.duration("CORS_MAX_AGE"),
+			AllowCredentials: l.boolean("CORS_ALLOW_CREDENTIALS"),
+		},
+	}
+	cfg.Default = cfg.Default.withDefaults(CORSPolicy{
+		AllowedMethods: defaultCORSMethods,
+		AllowedHeaders: defaultCORSHeaders,
+		ExposedHeaders: defaultCORSExposed,
+		MaxAge:         defaultCORSMaxAge,
+	})
+
+	groups := map[string]CORSPolicy{}
+	if err := l.v.UnmarshalKey("CORS_GROUPS", &groups); err != nil {
+		l.fail("CORS_GROUPS", "%v", err)
+	} else if len(groups) > 0 {
+		cfg.Groups = make(map[string]CORSPolicy, len(groups))
+		for group, policy := range groups {
+			cfg.Groups[strings.Trim(group, "/")] = policy.withDefaults(cfg.Default)
+		}
+	}
+
+	// reflecting any origin with credentials would let every site make
+	// authenticated requests
+	if cfg.Default.anyOriginWithCredentials() {
+		l.fail("CORS_ALLOWED_ORIGINS", "* cannot be combined with CORS_ALLOW_CREDENTIALS=true")
+	}
+	for group, policy := range cfg.Groups {
+		if policy.anyOriginWithCredentials() {
+			l.fail("CORS_GROUPS", "group %q combines allowed origin * with allow_credentials", group)
+		}
+	}
+
+	return cfg
+}
+
+func (p CORSPolicy) anyOriginWithCredentials() bool {
+	if !p.AllowCredentials {
+		return false
+	}
+	for _, origin := range p.AllowedOrigins {
+		if strings.TrimSpace(origin) == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// withDefaults fills every unset field of p from fallback. Credentials
+// are not inherited so a group can switch them off.
+func (p CORSPolicy) withDefaults(fallback CORSPolicy) CORSPolicy {
+	if len(p.AllowedOrigins) == 0 {
+		p.AllowedOrigins = fallback.AllowedOrigins
+	}
+	if len(p.AllowedMethods) == 0 {
+		p.AllowedMethods = fallback.AllowedMethods
+	}
+	if len(p.AllowedHeaders) == 0 {
+		p.AllowedHeaders = fallback.AllowedHeaders
+	}
+	if len(p.ExposedHeaders) == 0 {
+		p.ExposedHeaders = fallback.ExposedHeaders
+	}
+	if p.MaxAge == 0 {
+		p.MaxAge = fallback.MaxAge
+	}
+	return p
+}
@@ -0,0 +1,180 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// loader reads typed values out of viper and collects every validation
+// problem instead of stopping at the first one.
+type loader struct {
+	v        *viper.Viper
+	problems []string
+}
+
+func (l *loader) fail(key, format string, args ...interface{}) {
+	l.problems = append(l.problems, fmt.Sprintf("%s: %s", key, fmt.Sprintf(format, args...)))
+}
+
+func (l *loader) readSecretFiles() {
+	for _, s := range settings {
+		if !s.secret {
+			continue
+		}
+
+		path := l.v.GetString(s.key + "_FILE")
+		if path == "" {
+			continue
+		}
+
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			l.fail(s.key+"_FILE", "failed to read secret: %v", err)
+			continue
+		}
+		l.v.Set(s.key, strings.TrimRight(string(raw), "\r\n"))
+	}
+}
+
+func (l *loader) required(key string) string {
+	value := strings.TrimSpace(l.v.GetString(key))
+	if value == "" {
+		l.fail(key, "is required (set %s, %s_FILE or --%s)", key, key, flagName(key))
+	}
+	return value
+}
+
+func (l *loader) port(key string) string {
+	value := l.required(key)
+	if value == "" {
+		return value
+	}
+
+	port, err := strconv.Atoi(value)
+	if err != nil || port < 1 || port > 65535 {
+		l.fail(key, "%q is not a valid port (1-65535)", value)
+	}
+	return value
+}
+
+func (l *loader) integer(key string, min int) int {
+	value := strings.TrimSpace(l.v.GetString(key))
+	if value == "" {
+		return 0
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		l.fail(key, "%q is not an integer", value)
+		return 0
+	}
+	if n < min {
+		l.fail(key, "must be at least %d, got %d", min, n)
+	}
+	return n
+}
+
+func (l *loader) boolean(key string) bool {
+	value := strings.TrimSpace(l.v.GetString(key))
+	if value == "" {
+		return false
+	}
+
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		l.fail(key, "%q is not a boolean", value)
+	}
+	return b
+}
+
+func (l *loader) duration(key string) time.Duration {
+	value := strings.TrimSpace(l.v.GetString(key))
+	if value == "" {
+		return 0
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		l.fail(key, "%q is not a duration (e.g. 30s, 5m)", value)
+		return 0
+	}
+	if d < 0 {
+		l.fail(key, "must not be negative")
+	}
+	return d
+}
+
+// minutes is duration that also accepts a bare number of minutes, the
+// format DB_MAX_LIFE_TIME used before.
+func (l *loader) minutes(key string) time.Duration {
+	value := strings.TrimSpace(l.v.GetString(key))
+	if n, err := strconv.Atoi(value); err == nil {
+		if n < 0 {
+			l.fail(key, "must not be negative")
+		}
+		return time.Duration(n) * time.Minute
+	}
+	return l.duration(key)
+}
+
+// durations parses "name=duration" pairs separated by commas.
+func (l *loader) durations(key string) map[string]time.Duration {
+	items := splitList(l.v.GetString(key))
+	if len(items) == 0 {
+		return nil
+	}
+
+	values := make(map[string]time.Duration, len(items))
+	for _, item := range items {
+		name, value, ok := strings.Cut(item, "=")
+		d, err := time.ParseDuration(strings.TrimSpace(value))
+		if !ok || err != nil || d <= 0 {
+			l.fail(key, "%q must be written as name=duration", item)
+			continue
+		}
+		values[strings.TrimSpace(name)] = d
+	}
+	return values
+}
+
+// networks reads a list of IP addresses or CIDR ranges.
+func (l *loader) networks(key string) []string {
+	items := splitList(l.v.GetString(key))
+	for _, item := range items {
+		if _, _, err := net.ParseCIDR(item); err == nil {
+			continue
+		}
+		if net.ParseIP(item) == nil {
+			l.fail(key, "%q is not an IP address or CIDR range", item)
+		}
+	}
+	return items
+}
+
+func (l *loader) oneOf(key string, options ...string) string {
+	value := strings.ToLower(strings.TrimSpace(l.v.GetString(key)))
+	for _, option := range options {
+		if value == option {
+			return value
+		}
+	}
+
+	l.fail(key, "%q must be one of %s", value, strings.Join(options, ", "))
+	return value
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
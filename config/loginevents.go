@@ -0,0 +1,23 @@
+package config
+
+import "time"
+
+type LoginEventsConfig struct {
+	// Retention is how long login events are kept; zero keeps them
+	// forever.
+	Retention     time.Duration
+	PruneInterval time.Duration
+}
+
+func loadLoginEvents(l *loader) LoginEventsConfig {
+	cfg := LoginEventsConfig{
+		Retention:     l.duration("LOGIN_EVENTS_RETENTION"),
+		PruneInterval: l.duration("LOGIN_EVENTS_PRUNE_INTERVAL"),
+	}
+
+	if cfg.Retention > 0 && cfg.PruneInterval == 0 {
+		l.fail("LOGIN_EVENTS_PRUNE_INTERVAL", "must be set when LOGIN_EVENTS_RETENTION is")
+	}
+
+	return cfg
+}
@@ -0,0 +1,53 @@
+package config
+
+import (
+	"time"
+
+	"github.com/Reza1878/goesclearning/user-service/helper/outbox"
+)
+
+type OutboxConfig struct {
+	RelayEnabled bool
+	// Publisher is log, nats or kafka.
+	Publisher      string
+	NATSURL        string
+	KafkaRESTURL   string
+	PublishTimeout time.Duration
+	Relay          outbox.RelayConfig
+}
+
+func loadOutbox(l *loader) OutboxConfig {
+	cfg := OutboxConfig{
+		RelayEnabled:   l.boolean("OUTBOX_RELAY_ENABLED"),
+		Publisher:      l.oneOf("OUTBOX_PUBLISHER", "log", "nats", "kafka"),
+		NATSURL:        l.v.GetString("OUTBOX_NATS_URL"),
+		KafkaRESTURL:   l.v.GetString("OUTBOX_KAFKA_REST_URL"),
+		PublishTimeout: l.duration("OUTBOX_PUBLISH_TIMEOUT"),
+		Relay: outbox.RelayConfig{
+			BatchSize:    l.integer("OUTBOX_BATCH_SIZE", 1),
+			PollInterval: l.duration("OUTBOX_POLL_INTERVAL"),
+			Lease:        l.duration("OUTBOX_LEASE"),
+			MaxBackoff:   l.duration("OUTBOX_MAX_BACKOFF"),
+			Retention:    l.duration("OUTBOX_RETENTION"),
+		},
+	}
+
+	if cfg.Publisher == "nats" {
+		if _, err := outbox.NewNATSPublisher(cfg.NATSURL, cfg.PublishTimeout); err != nil {
+			l.fail("OUTBOX_NATS_URL", "%v", err)
+		}
+	}
+
+	if cfg.RelayEnabled {
+		if cfg.Relay.PollInterval == 0 {
+			l.fail("OUTBOX_POLL_INTERVAL", "must be set when the relay is enabled")
+		}
+		// a lease shorter than a publish lets another relay send the same
+		// event while the first is still trying
+		if cfg.Relay.Lease <= cfg.PublishTimeout {
+			l.fail("OUTBOX_LEASE", "must be longer than OUTBOX_PUBLISH_TIMEOUT (%s)", cfg.PublishTimeout)
+		}
+	}
+
+	return cfg
+}
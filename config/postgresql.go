@@ -37,16 +37,17 @@ func InitPostgreSQL(cfg PostgreSQLConfig) (*sql.DB, error) {
 
 	db, err := sql.Open("postgres", conn)
 	if err != nil {
-		panic(err)
+		return nil, fmt.Errorf("failed open postgres connection: %w", err)
 	}
 
 	db.SetMaxIdleConns(cfg.DbMaxIdleConn)
 	db.SetMaxOpenConns(cfg.DbMaxOpenConn)
-	db.SetConnMaxLifetime(cfg.DbMaxLifeTime * time.Minute)
+	db.SetConnMaxLifetime(cfg.DbMaxLifeTime)
 
 	err = db.Ping()
 	if err != nil {
-		panic(err)
+		db.Close()
+		return nil, fmt.Errorf("failed connect to postgres at %s:%s: %w", cfg.DbHost, cfg.DbPort, err)
 	}
 
 	return db, nil
@@ -0,0 +1,49 @@
+package config
+
+import (
+	"fmt"
+	"io"
+
+	"gopkg.in/yaml.v3"
+)
+
+const redacted = "******"
+
+// Print writes the effective configuration for args as YAML, with every
+// secret replaced. It does not validate, so it also helps to debug a
+// configuration that Load rejects.
+func Print(w io.Writer, args []string) error {
+	v, err := newViper(args)
+	if err != nil {
+		return err
+	}
+
+	l := &loader{v: v}
+	l.readSecretFiles()
+
+	values := make(map[string]interface{}, len(settings))
+	for _, s := range settings {
+		value := v.GetString(s.key)
+		if s.secret && value != "" {
+			value = redacted
+		}
+		values[s.key] = value
+	}
+	if groups := v.Get("CORS_GROUPS"); groups != nil {
+		values["CORS_GROUPS"] = groups
+	}
+
+	out, err := yaml.Marshal(values)
+	if err != nil {
+		return fmt.Errorf("failed to encode configuration: %w", err)
+	}
+
+	if _, err := w.Write(out); err != nil {
+		return err
+	}
+
+	for _, problem := range l.problems {
+		fmt.Fprintf(w, "# %s\n", problem)
+	}
+	return nil
+}
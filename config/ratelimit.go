@@ -0,0 +1,16 @@
+package config
+
+import "time"
+
+type RateLimitConfig struct {
+	// UsernameCheck limits GET /user/username-available per client IP.
+	UsernameCheck       int
+	UsernameCheckWindow time.Duration
+}
+
+func loadRateLimit(l *loader) RateLimitConfig {
+	return RateLimitConfig{
+		UsernameCheck:       l.integer("USERNAME_CHECK_RATE_LIMIT", 1),
+		UsernameCheckWindow: l.duration("USERNAME_CHECK_RATE_WINDOW"),
+	}
+}
@@ -3,6 +3,7 @@ package config
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/redis/go-redis/v9"
 )
@@ -13,6 +14,20 @@ type RedisConfig struct {
 	DB       int
 }
 
+type CacheConfig struct {
+	// Backend is redis or memory. The memory backend is per process, so
+	// idempotency keys and invalidations are not shared between replicas.
+	Backend          string
+	MemoryMaxEntries int
+
+	ProductEnabled bool
+	ProductTTL     time.Duration
+
+	UserEnabled     bool
+	UserTTL         time.Duration
+	UserNegativeTTL time.Duration
+}
+
 func InitRedis(cfg RedisConfig) (*redis.Client, error) {
 	rdb := redis.NewClient(&redis.Options{
 		Addr:     cfg.Address,
@@ -1,37 +1,290 @@
 package config
 
 import (
-	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
 	"fmt"
-	"log"
+	"log/slog"
+	"os"
+	"sort"
 	"time"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/keepalive"
 )
 
+const productServiceName = "proto.ProductService"
+
 type RPCConfig struct {
-	Port string
+	// Target is a gRPC target URI such as dns:///product:50051. When it is
+	// empty the client dials localhost:Port, the old behaviour.
+	Target        string
+	Port          string
+	LoadBalancing string
+
+	TLS RPCTLSConfig
+
+	KeepaliveTime    time.Duration
+	KeepaliveTimeout time.Duration
+
+	// Timeout applies to every method without an entry in MethodTimeouts.
+	Timeout        time.Duration
+	MethodTimeouts map[string]time.Duration
+
+	// RetryMethods lists the idempotent methods that may be retried on
+	// UNAVAILABLE.
+	RetryMethods        []string
+	RetryMaxAttempts    int
+	RetryInitialBackoff time.Duration
+	RetryMaxBackoff     time.Duration
+
+	// ServiceConfig, when set, is used verbatim instead of the service
+	// config generated from the fields above.
+	ServiceConfig string
+}
+
+type RPCTLSConfig struct {
+	Enabled    bool
+	CAFile     string
+	CertFile   string
+	KeyFile    string
+	ServerName string
+}
+
+func loadRPC(l *loader) RPCConfig {
+	cfg := RPCConfig{
+		Target:        l.v.GetString("RPC_TARGET"),
+		Port:          l.port("RPC_PORT"),
+		LoadBalancing: l.oneOf("RPC_LOAD_BALANCING", "round_robin", "pick_first"),
+
+		TLS: RPCTLSConfig{
+			Enabled:    l.boolean("RPC_TLS"),
+			CAFile:     l.v.GetString("RPC_TLS_CA_FILE"),
+			CertFile:   l.v.GetString("RPC_TLS_CERT_FILE"),
+			KeyFile:    l.v.GetString("RPC_TLS_KEY_FILE"),
+			ServerName: l.v.GetString("RPC_TLS_SERVER_NAME"),
+		},
+
+		KeepaliveTime:    l.duration("RPC_KEEPALIVE_TIME"),
+		KeepaliveTimeout: l.duration("RPC_KEEPALIVE_TIMEOUT"),
+
+		Timeout:        l.duration("RPC_TIMEOUT"),
+		MethodTimeouts: l.durations("RPC_METHOD_TIMEOUTS"),
+
+		RetryMethods:        splitList(l.v.GetString("RPC_RETRY_METHODS")),
+		RetryMaxAttempts:    l.integer("RPC_RETRY_MAX_ATTEMPTS", 1),
+		RetryInitialBackoff: l.duration("RPC_RETRY_INITIAL_BACKOFF"),
+		RetryMaxBackoff:     l.duration("RPC_RETRY_MAX_BACKOFF"),
+
+		ServiceConfig: l.v.GetString("RPC_SERVICE_CONFIG"),
+	}
+
+	if (cfg.TLS.CertFile == "") != (cfg.TLS.KeyFile == "") {
+		l.fail("RPC_TLS_CERT_FILE", "mutual TLS needs both RPC_TLS_CERT_FILE and RPC_TLS_KEY_FILE")
+	}
+	if !cfg.TLS.Enabled && (cfg.TLS.CAFile != "" || cfg.TLS.CertFile != "") {
+		l.fail("RPC_TLS", "must be true when RPC_TLS_CA_FILE or RPC_TLS_CERT_FILE is set")
+	}
+	if cfg.RetryMaxAttempts > 5 {
+		l.fail("RPC_RETRY_MAX_ATTEMPTS", "gRPC caps retries at 5 attempts, got %d", cfg.RetryMaxAttempts)
+	}
+	if cfg.ServiceConfig != "" && !json.Valid([]byte(cfg.ServiceConfig)) {
+		l.fail("RPC_SERVICE_CONFIG", "is not valid JSON")
+	}
+	// the generated retry policy needs both backoffs; gRPC only rejects
+	// it when dialling
+	if cfg.ServiceConfig == "" && cfg.RetryMaxAttempts > 1 && len(cfg.RetryMethods) > 0 {
+		if cfg.RetryInitialBackoff < time.Millisecond {
+			l.fail("RPC_RETRY_INITIAL_BACKOFF", "must be at least 1ms when retries are enabled, got %s", cfg.RetryInitialBackoff)
+		}
+		if cfg.RetryMaxBackoff < time.Millisecond {
+			l.fail("RPC_RETRY_MAX_BACKOFF", "must be at least 1ms when retries are enabled, got %s", cfg.RetryMaxBackoff)
+		}
+		if cfg.RetryInitialBackoff > cfg.RetryMaxBackoff {
+			l.fail("RPC_RETRY_INITIAL_BACKOFF", "must not exceed RPC_RETRY_MAX_BACKOFF (%s)", cfg.RetryMaxBackoff)
+		}
+	}
+
+	return cfg
+}
+
+func (c RPCConfig) target() string {
+	if c.Target != "" {
+		return c.Target
+	}
+	return fmt.Sprintf("localhost:%s", c.Port)
 }
 
 func RPCDial(cfg RPCConfig) (*grpc.ClientConn, error) {
-	address := fmt.Sprintf("localhost:%s", cfg.Port)
+	address := cfg.target()
+
+	creds, err := rpcCredentials(cfg.TLS)
+	if err != nil {
+		return nil, err
+	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
+	serviceConfig := cfg.ServiceConfig
+	if serviceConfig == "" {
+		serviceConfig, err = rpcServiceConfig(cfg)
+		if err != nil {
+			return nil, err
+		}
+	}
 
-	conn, err := grpc.DialContext(ctx, address,
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	opts := []grpc.DialOption{
+		grpc.WithTransportCredentials(creds),
+		grpc.WithDefaultServiceConfig(serviceConfig),
 		grpc.WithDefaultCallOptions(
 			grpc.MaxCallRecvMsgSize(1024*1024*64),
 			grpc.MaxCallSendMsgSize(1024*1024*64),
 		),
-	)
+	}
 
+	if cfg.KeepaliveTime > 0 {
+		opts = append(opts, grpc.WithKeepaliveParams(keepalive.ClientParameters{
+			Time:    cfg.KeepaliveTime,
+			Timeout: cfg.KeepaliveTimeout,
+		}))
+	}
+
+	conn, err := grpc.NewClient(address, opts...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create gRPC client: %w", err)
 	}
+	conn.Connect()
 
-	log.Printf("[Success] - Connected to RPC server at %s", address)
+	slog.Info("created RPC client",
+		slog.String("target", address),
+		slog.String("load_balancing", cfg.LoadBalancing),
+		slog.Bool("tls", cfg.TLS.Enabled),
+	)
 	return conn, nil
 }
+
+func rpcCredentials(cfg RPCTLSConfig) (credentials.TransportCredentials, error) {
+	if !cfg.Enabled {
+		return insecure.NewCredentials(), nil
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ServerName: cfg.ServerName,
+	}
+
+	if cfg.CAFile != "" {
+		ca, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read RPC CA file: %w", err)
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("no certificates found in RPC CA file %s", cfg.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if cfg.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load RPC client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return credentials.NewTLS(tlsConfig), nil
+}
+
+type methodName struct {
+	Service string `json:"service"`
+	Method  string `json:"method,omitempty"`
+}
+
+type retryPolicy struct {
+	MaxAttempts          int      `json:"maxAttempts"`
+	InitialBackoff       string   `json:"initialBackoff"`
+	MaxBackoff           string   `json:"maxBackoff"`
+	BackoffMultiplier    float64  `json:"backoffMultiplier"`
+	RetryableStatusCodes []string `json:"retryableStatusCodes"`
+}
+
+type methodConfig struct {
+	Name        []methodName `json:"name"`
+	Timeout     string       `json:"timeout,omitempty"`
+	RetryPolicy *retryPolicy `json:"retryPolicy,omitempty"`
+}
+
+type serviceConfig struct {
+	LoadBalancingConfig []map[string]struct{} `json:"loadBalancingConfig"`
+	MethodConfig        []methodConfig        `json:"methodConfig"`
+}
+
+// rpcServiceConfig builds the gRPC service config JSON for the product
+// service: the balancing policy, a default timeout and one entry per
+// method that has its own timeout or may be retried.
+func rpcServiceConfig(cfg RPCConfig) (string, error) {
+	sc := serviceConfig{
+		LoadBalancingConfig: []map[string]struct{}{{cfg.LoadBalancing: {}}},
+		MethodConfig: []methodConfig{{
+			Name:    []methodName{{Service: productServiceName}},
+			Timeout: protoDuration(cfg.Timeout),
+		}},
+	}
+
+	methods := map[string]*methodConfig{}
+	var order []string
+	method := func(name string) *methodConfig {
+		if mc, ok := methods[name]; ok {
+			return mc
+		}
+		mc := &methodConfig{
+			Name:    []methodName{{Service: productServiceName, Method: name}},
+			Timeout: protoDuration(cfg.Timeout),
+		}
+		methods[name] = mc
+		order = append(order, name)
+		return mc
+	}
+
+	timeoutMethods := make([]string, 0, len(cfg.MethodTimeouts))
+	for name := range cfg.MethodTimeouts {
+		timeoutMethods = append(timeoutMethods, name)
+	}
+	sort.Strings(timeoutMethods)
+
+	for _, name := range timeoutMethods {
+		method(name).Timeout = protoDuration(cfg.MethodTimeouts[name])
+	}
+
+	if cfg.RetryMaxAttempts > 1 {
+		for _, name := range cfg.RetryMethods {
+			method(name).RetryPolicy = &retryPolicy{
+				MaxAttempts:          cfg.RetryMaxAttempts,
+				InitialBackoff:       protoDuration(cfg.RetryInitialBackoff),
+				MaxBackoff:           protoDuration(cfg.RetryMaxBackoff),
+				BackoffMultiplier:    2,
+				RetryableStatusCodes: []string{"UNAVAILABLE"},
+			}
+		}
+	}
+
+	for _, name := range order {
+		sc.MethodConfig = append(sc.MethodConfig, *methods[name])
+	}
+
+	raw, err := json.Marshal(sc)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode RPC service config: %w", err)
+	}
+	return string(raw), nil
+}
+
+func protoDuration(d time.Duration) string {
+	if d <= 0 {
+		return ""
+	}
+	return fmt.Sprintf("%.3fs", d.Seconds())
+}
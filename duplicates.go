@@ -0,0 +1,69 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Reza1878/goesclearning/user-service/config"
+	"github.com/Reza1878/goesclearning/user-service/helper/email"
+	"github.com/Reza1878/goesclearning/user-service/model"
+	repository "github.com/Reza1878/goesclearning/user-service/repository/user"
+)
+
+// reportDuplicateEmails lists accounts that collide once their emails are
+// normalised with the configured folding. It only reads; merging the
+// accounts is left to an operator.
+func reportDuplicateEmails(w io.Writer, args []string) error {
+	cfg, err := config.Load(args)
+	if errors.Is(err, config.ErrHelp) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	email.SetFolding(email.Folding(cfg.EmailFolding))
+
+	db, err := config.InitPostgreSQL(cfg.Postgres)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	report, err := repository.NewStore(db).FindEmailDuplicates(email.Normalize)
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "NORMALIZED\tID\tEMAIL\tCREATED AT")
+	for _, group := range report.Duplicates {
+		for _, user := range group.Users {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", group.Normalized, user.Id, user.Email, createdAt(user))
+		}
+	}
+	for _, user := range report.Invalid {
+		fmt.Fprintf(tw, "(invalid)\t%s\t%s\t%s\n", user.Id, user.Email, createdAt(user))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	accounts := 0
+	for _, group := range report.Duplicates {
+		accounts += len(group.Users)
+	}
+	fmt.Fprintf(w, "\n%d duplicate addresses across %d accounts, %d invalid emails, %d emails not in normalised form\n",
+		len(report.Duplicates), accounts, len(report.Invalid), len(report.Unnormalized))
+
+	return nil
+}
+
+func createdAt(user model.User) string {
+	if user.CreatedAt == nil {
+		return "-"
+	}
+	return user.CreatedAt.Format(time.RFC3339)
+}
@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/Reza1878/goesclearning/user-service/helper/audit"
+	"github.com/Reza1878/goesclearning/user-service/helper/fault"
+	"github.com/Reza1878/goesclearning/user-service/helper/response"
+	"github.com/Reza1878/goesclearning/user-service/model"
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	log *audit.Log
+}
+
+func NewHandler(log *audit.Log) *Handler {
+	return &Handler{log: log}
+}
+
+type auditListRes struct {
+	Items []audit.Entry  `json:"items"`
+	Meta  model.ListMeta `json:"meta"`
+}
+
+func (h *Handler) HandleListAudit(ctx *gin.Context) {
+	var query model.AuditQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		fault.Response(ctx, fault.Validation(err))
+		return
+	}
+
+	entries, total, err := h.log.List(ctx, audit.Filter{
+		ActorId:    query.ActorId,
+		Action:     query.Action,
+		TargetType: query.TargetType,
+		TargetId:   query.TargetId,
+		From:       query.From,
+		To:         query.To,
+		Page:       query.Page,
+		Limit:      query.Limit,
+	})
+	if err != nil {
+		fault.ErrorHandler(ctx, err)
+		return
+	}
+
+	response.JSON(ctx, http.StatusOK, response.MsgSuccess, auditListRes{
+		Items: entries,
+		Meta: model.ListMeta{
+			TotalData:   total,
+			TotalPage:   (total + query.Limit - 1) / query.Limit,
+			CurrentPage: query.Page,
+			Limit:       query.Limit,
+		},
+	})
+}
@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/Reza1878/goesclearning/user-service/helper/fault"
+	"github.com/Reza1878/goesclearning/user-service/helper/money"
+	"github.com/Reza1878/goesclearning/user-service/model"
+	"github.com/Reza1878/goesclearning/user-service/proto/product"
+)
+
+// priceFromRequest reads the price of an insert or update request.
+// price_money wins; the deprecated float price is converted in
+// DefaultCurrency. It returns nil when neither is set.
+func priceFromRequest(field string, priceMoney *model.Money, price *float64) (*money.Money, error) {
+	switch {
+	case priceMoney != nil:
+		m, err := money.Parse(priceMoney.Amount, priceMoney.Currency)
+		if err != nil {
+			return nil, amountError(field+".amount", priceMoney.Amount, err)
+		}
+		if m.Minor <= 0 {
+			return nil, fault.Custom(
+				http.StatusBadRequest,
+				fault.ErrBadRequest,
+				fmt.Sprintf("%s must be positive, got %s", field, priceMoney.Amount),
+			).WithDetails(fault.FieldError(field+".amount", "gt", "0"))
+		}
+		return &m, nil
+
+	case price != nil:
+		m, err := money.FromFloat(*price, money.DefaultCurrency)
+		if err != nil {
+			return nil, amountError("price", fmt.Sprint(*price), err)
+		}
+		return &m, nil
+	}
+
+	return nil, nil
+}
+
+// filterPrice reads a min_price or max_price query parameter.
+func filterPrice(field, amount, currency string) (*money.Money, error) {
+	if amount == "" {
+		return nil, nil
+	}
+
+	m, err := money.Parse(amount, currency)
+	if err != nil {
+		return nil, amountError(field, amount, err)
+	}
+	if m.Minor < 0 {
+		return nil, fault.Custom(
+			http.StatusBadRequest,
+			fault.ErrBadRequest,
+			fmt.Sprintf("%s must not be negative, got %s", field, amount),
+		).WithDetails(fault.FieldError(field, "gte", "0"))
+	}
+	return &m, nil
+}
+
+func amountError(field, amount string, err error) error {
+	rule := "decimal"
+	if errors.Is(err, money.ErrPrecision) {
+		rule = "precision"
+	}
+
+	return fault.Custom(
+		http.StatusBadRequest,
+		fault.ErrBadRequest,
+		fmt.Sprintf("invalid %s %q: %v", field, amount, err),
+	).WithDetails(fault.FieldError(field, rule, ""))
+}
+
+func moneyToProto(m *money.Money) *product.Money {
+	if m == nil {
+		return nil
+	}
+	return &product.Money{MinorUnits: m.Minor, Currency: m.Currency}
+}
+
+func moneyFromProto(m *product.Money) money.Money {
+	return money.Money{Minor: m.GetMinorUnits(), Currency: m.GetCurrency()}
+}
+
+func moneyResponse(m money.Money) model.Money {
+	return model.Money{Amount: m.String(), Currency: m.Currency}
+}
+
+// productPrice falls back to the float price for product services that
+// do not send price_money yet.
+func productPrice(p *product.Product) money.Money {
+	if p.GetPriceMoney() != nil {
+		return moneyFromProto(p.GetPriceMoney())
+	}
+
+	m, err := money.FromFloat(float64(p.GetPrice()), money.DefaultCurrency)
+	if err != nil {
+		return money.Money{Currency: money.DefaultCurrency}
+	}
+	return m
+}
+
+func productResponse(p *product.Product) model.ProductRes {
+	price := productPrice(p)
+
+	return model.ProductRes{
+		Id:          p.GetId(),
+		UserId:      p.GetUserId(),
+		Name:        p.GetName(),
+		Description: p.GetDescription(),
+		Qty:         p.GetQty(),
+		PriceMoney:  moneyResponse(price),
+		Price:       price.Float(),
+	}
+}
+
+func productListResponse(res *product.ListProductResponse) model.ProductListRes {
+	out := model.ProductListRes{
+		Items: make([]model.ProductRes, 0, len(res.GetItems())),
+	}
+	for _, item := range res.GetItems() {
+		out.Items = append(out.Items, productResponse(item))
+	}
+
+	if meta := res.GetMeta(); meta != nil {
+		out.Meta = &model.ProductMeta{
+			TotalData:   meta.GetTotalData(),
+			TotalPage:   meta.GetTotalPage(),
+			CurrentPage: meta.GetCurrentPage(),
+			Limit:       meta.GetLimit(),
+		}
+	}
+
+	if stats := res.GetStats(); stats != nil {
+		out.Stats = &model.ProductStats{
+			Count:          stats.GetCount(),
+			TotalQty:       stats.GetTotalQty(),
+			InventoryValue: stats.GetInventoryValue(),
+		}
+		if value := stats.GetInventoryValueMoney(); value != nil {
+			m := moneyResponse(moneyFromProto(value))
+			out.Stats.InventoryValueMoney = &m
+			out.Stats.InventoryValue = moneyFromProto(value).Float()
+		}
+	}
+
+	return out
+}
@@ -2,11 +2,14 @@ package handlers
 
 import (
 	"fmt"
+	"math"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/Reza1878/goesclearning/user-service/helper/fault"
 	"github.com/Reza1878/goesclearning/user-service/helper/jwt"
+	"github.com/Reza1878/goesclearning/user-service/helper/money"
 	"github.com/Reza1878/goesclearning/user-service/helper/response"
 	"github.com/Reza1878/goesclearning/user-service/model"
 	"github.com/Reza1878/goesclearning/user-service/proto/product"
@@ -14,6 +17,8 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
+const IdempotencyKeyHeader = "Idempotency-Key"
+
 type Handler struct {
 	service usecases.ProductUsecases
 }
@@ -25,31 +30,28 @@ func NewProductUsecase(service usecases.ProductUsecases) *Handler {
 func (h *Handler) InsertProduct(ctx *gin.Context) {
 	var req model.ProductInsertReq
 
-	token, err := jwt.GetTokenFromHeader(ctx)
+	claims, err := jwt.ClaimsFromContext(ctx)
 	if err != nil {
 		fault.Response(ctx, err)
 		return
 	}
 
-	claims, err := jwt.GetClaims(token)
-	if err != nil {
-		fault.Response(ctx, err)
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		fault.Response(ctx, fault.Validation(err))
 		return
 	}
 
-	if err := ctx.ShouldBindJSON(&req); err != nil {
-		fault.Response(ctx, fault.Custom(
-			http.StatusBadRequest,
-			fault.ErrBadRequest,
-			fmt.Sprintf("failed to bind JSON: %v", err),
-		))
+	price, err := priceFromRequest("price_money", req.PriceMoney, req.Price)
+	if err != nil {
+		fault.Response(ctx, err)
 		return
 	}
 
 	bRes, err := h.service.InsertProduct(ctx, &product.ProductInsertRequest{
 		Name:        req.Name,
 		Description: req.Description,
-		Price:       req.Price,
+		Price:       float32(price.Float()),
+		PriceMoney:  moneyToProto(price),
 		Qty:         uint32(req.Qty),
 		UserId:      claims.UserId,
 	})
@@ -57,38 +59,246 @@ func (h *Handler) InsertProduct(ctx *gin.Context) {
 		fault.Response(ctx, err)
 		return
 	}
-	response.JSON(ctx, http.StatusCreated, "Success", bRes)
+	response.JSON(ctx, http.StatusCreated, response.MsgSuccess, bRes)
 }
 
 func (h *Handler) ListProduct(ctx *gin.Context) {
-	page, err := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	req, err := bindListQuery(ctx)
+	if err != nil {
+		fault.Response(ctx, err)
+		return
+	}
+
+	res, err := h.service.ListProduct(ctx, req)
+	if err != nil {
+		fault.Response(ctx, err)
+		return
+	}
+
+	response.JSON(ctx, http.StatusOK, response.MsgSuccess, productListResponse(res))
+}
+
+func (h *Handler) ListMyProducts(ctx *gin.Context) {
+	claims, err := jwt.ClaimsFromContext(ctx)
+	if err != nil {
+		fault.Response(ctx, err)
+		return
+	}
+
+	req, err := bindListQuery(ctx)
+	if err != nil {
+		fault.Response(ctx, err)
+		return
+	}
+
+	res, err := h.service.ListUserProducts(ctx, claims.UserId, req)
+	if err != nil {
+		fault.Response(ctx, err)
+		return
+	}
+
+	response.JSON(ctx, http.StatusOK, response.MsgSuccess, productListResponse(res))
+}
+
+func bindListQuery(ctx *gin.Context) (*product.ListProductRequest, error) {
+	page, err := strconv.ParseUint(ctx.DefaultQuery("page", "1"), 10, 64)
+	if err != nil || page < 1 {
+		return nil, fault.Custom(
+			http.StatusBadRequest,
+			fault.ErrBadRequest,
+			fmt.Sprintf("invalid or missing page number: %q", ctx.Query("page")),
+		).WithDetails(fault.FieldError("page", "number", ""))
+	}
+
+	limit, err := strconv.ParseUint(ctx.DefaultQuery("limit", "10"), 10, 64)
+	if err != nil || limit < 1 {
+		return nil, fault.Custom(
+			http.StatusBadRequest,
+			fault.ErrBadRequest,
+			fmt.Sprintf("invalid or missing limit number: %q", ctx.Query("limit")),
+		).WithDetails(fault.FieldError("limit", "number", ""))
+	}
+
+	var filter model.ProductListFilter
+	if err := ctx.ShouldBindQuery(&filter); err != nil {
+		return nil, fault.Validation(err)
+	}
+
+	currency := filter.Currency
+	if currency == "" {
+		currency = money.DefaultCurrency
+	}
+
+	minPrice, err := filterPrice("min_price", filter.MinPrice, currency)
+	if err != nil {
+		return nil, err
+	}
+	maxPrice, err := filterPrice("max_price", filter.MaxPrice, currency)
+	if err != nil {
+		return nil, err
+	}
+
+	if minPrice != nil && maxPrice != nil && minPrice.Minor > maxPrice.Minor {
+		return nil, fault.Custom(
+			http.StatusBadRequest,
+			fault.ErrBadRequest,
+			fmt.Sprintf("min_price %s is greater than max_price %s", minPrice, maxPrice),
+		).WithDetails(fault.FieldError("min_price", "lte", "max_price"))
+	}
+
+	ids := filter.ProductIDs()
+	if len(ids) > model.MaxListProductIDs {
+		return nil, fault.Custom(
+			http.StatusBadRequest,
+			fault.ErrBadRequest,
+			fmt.Sprintf("too many product ids: %d", len(ids)),
+		).WithDetails(fault.FieldError("ids", "max", strconv.Itoa(model.MaxListProductIDs)))
+	}
+
+	// clamp in uint64 before the uint32 cast so huge values cannot wrap
+	// around, on 32-bit platforms too
+	page = min(page, math.MaxUint32)
+	limit = min(limit, usecases.MaxListLimit)
+
+	return &product.ListProductRequest{
+		Page:          uint32(page),
+		Limit:         uint32(limit),
+		ProductIds:    ids,
+		Q:             strings.TrimSpace(filter.Q),
+		MinPrice:      legacyPrice(minPrice),
+		MaxPrice:      legacyPrice(maxPrice),
+		MinPriceMoney: moneyToProto(minPrice),
+		MaxPriceMoney: moneyToProto(maxPrice),
+		UserId:        filter.UserId,
+		Sort:          filter.Sort,
+	}, nil
+}
+
+func legacyPrice(m *money.Money) *float32 {
+	if m == nil {
+		return nil
+	}
+	f := float32(m.Float())
+	return &f
+}
+
+func (h *Handler) GetProduct(ctx *gin.Context) {
+	res, err := h.service.GetProduct(ctx, ctx.Param("id"))
+	if err != nil {
+		fault.Response(ctx, err)
+		return
+	}
+
+	response.JSON(ctx, http.StatusOK, response.MsgSuccess, productResponse(res))
+}
+
+func (h *Handler) UpdateProduct(ctx *gin.Context) {
+	var req model.ProductUpdateReq
+
+	claims, err := jwt.ClaimsFromContext(ctx)
 	if err != nil {
+		fault.Response(ctx, err)
+		return
+	}
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		fault.Response(ctx, fault.Validation(err))
+		return
+	}
+
+	if req.IsEmpty() {
 		fault.Response(ctx, fault.Custom(
 			http.StatusBadRequest,
 			fault.ErrBadRequest,
-			fmt.Sprintf("invalid or missing page number: %v", err.Error()),
+			"update request has no fields",
 		))
 		return
 	}
 
-	limit, err := strconv.Atoi(ctx.DefaultQuery("limit", "10"))
+	price, err := priceFromRequest("price_money", req.PriceMoney, req.Price)
+	if err != nil {
+		fault.Response(ctx, err)
+		return
+	}
+
+	updateReq := &product.UpdateProductRequest{
+		Id:          ctx.Param("id"),
+		Name:        req.Name,
+		Price:       legacyPrice(price),
+		PriceMoney:  moneyToProto(price),
+		Description: req.Description,
+	}
+	if req.Qty != nil {
+		qty := uint32(*req.Qty)
+		updateReq.Qty = &qty
+	}
+
+	res, err := h.service.UpdateProduct(ctx, claims, updateReq)
+	if err != nil {
+		fault.Response(ctx, err)
+		return
+	}
+
+	response.JSON(ctx, http.StatusOK, response.MsgSuccess, productResponse(res))
+}
+
+func (h *Handler) DeleteProduct(ctx *gin.Context) {
+	claims, err := jwt.ClaimsFromContext(ctx)
+	if err != nil {
+		fault.Response(ctx, err)
+		return
+	}
+
+	res, err := h.service.DeleteProduct(ctx, claims, ctx.Param("id"))
+	if err != nil {
+		fault.Response(ctx, err)
+		return
+	}
+
+	response.JSON(ctx, http.StatusOK, response.MsgSuccess, res)
+}
+
+func (h *Handler) ReduceProductQty(ctx *gin.Context) {
+	var req model.ReduceProductReq
+
+	claims, err := jwt.ClaimsFromContext(ctx)
 	if err != nil {
+		fault.Response(ctx, err)
+		return
+	}
+
+	idempotencyKey := ctx.GetHeader(IdempotencyKeyHeader)
+	if idempotencyKey == "" || len(idempotencyKey) > 255 {
 		fault.Response(ctx, fault.Custom(
 			http.StatusBadRequest,
 			fault.ErrBadRequest,
-			fmt.Sprintf("invalid or missing limit number: %v", err.Error()),
-		))
+			"missing or oversized Idempotency-Key header",
+		).WithDetails(fault.FieldError(IdempotencyKeyHeader, "required", "")))
+		return
+	}
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		fault.Response(ctx, fault.Validation(err))
 		return
 	}
 
-	res, err := h.service.ListProduct(ctx, &product.ListProductRequest{
-		Page:  uint32(page),
-		Limit: uint32(limit),
+	items := make([]*product.ReduceProductItem, 0, len(req.Items))
+	for _, item := range req.Items {
+		items = append(items, &product.ReduceProductItem{
+			ProductId: item.ProductId,
+			Qty:       uint32(item.Qty),
+		})
+	}
+
+	res, err := h.service.ReduceProductQty(ctx, &product.ReduceProductRequest{
+		Items:          items,
+		IdempotencyKey: idempotencyKey,
+		UserId:         claims.UserId,
 	})
 	if err != nil {
 		fault.Response(ctx, err)
 		return
 	}
 
-	response.JSON(ctx, http.StatusOK, "Success", res)
+	response.JSON(ctx, http.StatusOK, response.MsgSuccess, res)
 }
@@ -0,0 +1,228 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Reza1878/goesclearning/user-service/helper/audit"
+	"github.com/Reza1878/goesclearning/user-service/helper/cache"
+	"github.com/Reza1878/goesclearning/user-service/helper/jwt"
+	"github.com/Reza1878/goesclearning/user-service/model"
+	"github.com/Reza1878/goesclearning/user-service/proto/product"
+	"github.com/Reza1878/goesclearning/user-service/proto/product/fake"
+	usecases "github.com/Reza1878/goesclearning/user-service/usecases/product"
+	"github.com/gin-gonic/gin"
+)
+
+const testUserHeader = "X-Test-User"
+
+// newTestRouter mounts the product routes in front of the fake product
+// service. Authentication is replaced by the X-Test-User header.
+func newTestRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	conn, stop, err := fake.Dial(fake.NewServer())
+	if err != nil {
+		t.Fatalf("dial fake product service: %v", err)
+	}
+	t.Cleanup(stop)
+
+	service := usecases.NewProductUsecase(product.NewProductServiceClient(conn), cache.NewMemory(100), audit.New(audit.NewMemory()))
+	h := NewProductUsecase(service)
+
+	authenticate := func(ctx *gin.Context) {
+		if user := ctx.GetHeader(testUserHeader); user != "" {
+			ctx.Set(jwt.ClaimsKey, &jwt.JWTPayload{UserId: user, Role: model.RoleUser})
+		}
+		ctx.Next()
+	}
+
+	router := gin.New()
+	router.GET("/user/me/products", authenticate, h.ListMyProducts)
+	group := router.Group("/product")
+	group.POST("/", authenticate, h.InsertProduct)
+	group.GET("/", h.ListProduct)
+	group.POST("/reduce", authenticate, h.ReduceProductQty)
+	group.GET("/:id", h.GetProduct)
+	group.PATCH("/:id", authenticate, h.UpdateProduct)
+	group.DELETE("/:id", authenticate, h.DeleteProduct)
+	return router
+}
+
+type envelope struct {
+	StatusCode int             `json:"status_code"`
+	Code       string          `json:"code"`
+	Data       json.RawMessage `json:"data"`
+}
+
+func do(t *testing.T, router *gin.Engine, method, path, user, body string, header http.Header) (int, envelope) {
+	t.Helper()
+
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if user != "" {
+		req.Header.Set(testUserHeader, user)
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	var res envelope
+	if err := json.Unmarshal(rec.Body.Bytes(), &res); err != nil {
+		t.Fatalf("%s %s: invalid body %q: %v", method, path, rec.Body.String(), err)
+	}
+	return rec.Code, res
+}
+
+func insertProduct(t *testing.T, router *gin.Engine, qty int) string {
+	t.Helper()
+
+	body := `{"name":"Keyboard","description":"mechanical","price_money":{"amount":"19.99","currency":"USD"},"qty":` + jsonInt(qty) + `}`
+	code, res := do(t, router, http.MethodPost, "/product/", "owner", body, nil)
+	if code != http.StatusCreated {
+		t.Fatalf("insert: status %d, body %s", code, res.Data)
+	}
+
+	var created model.ProductInsertRes
+	if err := json.Unmarshal(res.Data, &created); err != nil {
+		t.Fatalf("insert response: %v", err)
+	}
+	// the message is "product <id> created"
+	return strings.Fields(created.Msg)[1]
+}
+
+func jsonInt(n int) string {
+	raw, _ := json.Marshal(n)
+	return string(raw)
+}
+
+func TestProductRoutes(t *testing.T) {
+	idempotencyKey := http.Header{IdempotencyKeyHeader: {"reduce-1"}}
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		user   string
+		body   string
+		header http.Header
+		want   int
+		code   string
+	}{
+		{name: "insert without auth", method: http.MethodPost, path: "/product/", body: `{}`, want: http.StatusUnauthorized, code: "UNAUTHORIZED"},
+		{name: "insert without price", method: http.MethodPost, path: "/product/", user: "owner", body: `{"name":"a","description":"b","qty":1}`, want: http.StatusBadRequest, code: "BAD_REQUEST"},
+		{name: "insert with negative qty", method: http.MethodPost, path: "/product/", user: "owner", body: `{"name":"a","description":"b","price_money":{"amount":"1.00","currency":"USD"},"qty":-1}`, want: http.StatusBadRequest, code: "BAD_REQUEST"},
+		{name: "insert with huge qty", method: http.MethodPost, path: "/product/", user: "owner", body: `{"name":"a","description":"b","price_money":{"amount":"1.00","currency":"USD"},"qty":4294967297}`, want: http.StatusBadRequest, code: "BAD_REQUEST"},
+		{name: "insert with bad amount", method: http.MethodPost, path: "/product/", user: "owner", body: `{"name":"a","description":"b","price_money":{"amount":"1.999","currency":"USD"},"qty":1}`, want: http.StatusBadRequest, code: "BAD_REQUEST"},
+		{name: "get", method: http.MethodGet, path: "/product/{id}", want: http.StatusOK},
+		{name: "get missing", method: http.MethodGet, path: "/product/missing", want: http.StatusNotFound, code: "NOT_FOUND"},
+		{name: "list", method: http.MethodGet, path: "/product/", want: http.StatusOK},
+		{name: "list with invalid page", method: http.MethodGet, path: "/product/?page=abc", want: http.StatusBadRequest, code: "BAD_REQUEST"},
+		{name: "list with huge page", method: http.MethodGet, path: "/product/?page=99999999999", want: http.StatusOK},
+		{name: "list with huge limit", method: http.MethodGet, path: "/product/?limit=4294967297", want: http.StatusOK},
+		{name: "list mine without auth", method: http.MethodGet, path: "/user/me/products", want: http.StatusUnauthorized, code: "UNAUTHORIZED"},
+		{name: "list mine", method: http.MethodGet, path: "/user/me/products", user: "owner", want: http.StatusOK},
+		{name: "update without fields", method: http.MethodPatch, path: "/product/{id}", user: "owner", body: `{}`, want: http.StatusBadRequest, code: "BAD_REQUEST"},
+		{name: "update with huge qty", method: http.MethodPatch, path: "/product/{id}", user: "owner", body: `{"qty":4294967297}`, want: http.StatusBadRequest, code: "BAD_REQUEST"},
+		{name: "update by stranger", method: http.MethodPatch, path: "/product/{id}", user: "stranger", body: `{"qty":1}`, want: http.StatusForbidden, code: "FORBIDDEN"},
+		{name: "update by owner", method: http.MethodPatch, path: "/product/{id}", user: "owner", body: `{"qty":8}`, want: http.StatusOK},
+		{name: "reduce without key", method: http.MethodPost, path: "/product/reduce", user: "owner", body: `{"items":[{"product_id":"{id}","qty":1}]}`, want: http.StatusBadRequest, code: "BAD_REQUEST"},
+		{name: "reduce too much", method: http.MethodPost, path: "/product/reduce", user: "owner", body: `{"items":[{"product_id":"{id}","qty":9}]}`, header: http.Header{IdempotencyKeyHeader: {"reduce-0"}}, want: http.StatusConflict, code: "INSUFFICIENT_STOCK"},
+		{name: "reduce", method: http.MethodPost, path: "/product/reduce", user: "owner", body: `{"items":[{"product_id":"{id}","qty":3}]}`, header: idempotencyKey, want: http.StatusOK},
+		{name: "reduce replay", method: http.MethodPost, path: "/product/reduce", user: "owner", body: `{"items":[{"product_id":"{id}","qty":3}]}`, header: idempotencyKey, want: http.StatusOK},
+		{name: "reduce reused key", method: http.MethodPost, path: "/product/reduce", user: "owner", body: `{"items":[{"product_id":"{id}","qty":4}]}`, header: idempotencyKey, want: http.StatusUnprocessableEntity, code: "IDEMPOTENCY_KEY_REUSED"},
+		{name: "delete by stranger", method: http.MethodDelete, path: "/product/{id}", user: "stranger", want: http.StatusForbidden, code: "FORBIDDEN"},
+		{name: "delete by owner", method: http.MethodDelete, path: "/product/{id}", user: "owner", want: http.StatusOK},
+		{name: "get deleted", method: http.MethodGet, path: "/product/{id}", want: http.StatusNotFound, code: "NOT_FOUND"},
+	}
+
+	// the cases share one product and run in order
+	router := newTestRouter(t)
+	id := insertProduct(t, router, 10)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := strings.ReplaceAll(tt.path, "{id}", id)
+			body := strings.ReplaceAll(tt.body, "{id}", id)
+
+			code, res := do(t, router, tt.method, path, tt.user, body, tt.header)
+			if code != tt.want {
+				t.Fatalf("status = %d, want %d (code %q)", code, tt.want, res.Code)
+			}
+			if res.Code != tt.code {
+				t.Fatalf("error code = %q, want %q", res.Code, tt.code)
+			}
+		})
+	}
+}
+
+func TestProductResponseShape(t *testing.T) {
+	router := newTestRouter(t)
+	id := insertProduct(t, router, 10)
+
+	code, res := do(t, router, http.MethodGet, "/product/"+id, "", "", nil)
+	if code != http.StatusOK {
+		t.Fatalf("status = %d", code)
+	}
+
+	var got model.ProductRes
+	if err := json.Unmarshal(res.Data, &got); err != nil {
+		t.Fatalf("decode product: %v", err)
+	}
+	want := model.ProductRes{
+		Id:          id,
+		UserId:      "owner",
+		Name:        "Keyboard",
+		Description: "mechanical",
+		Qty:         10,
+		PriceMoney:  model.Money{Amount: "19.99", Currency: "USD"},
+		Price:       got.Price,
+	}
+	if got != want {
+		t.Fatalf("product = %+v, want %+v", got, want)
+	}
+	if got.Price < 19.98 || got.Price > 20 {
+		t.Fatalf("deprecated price = %v, want about 19.99", got.Price)
+	}
+}
+
+func TestProductListClampsPaging(t *testing.T) {
+	router := newTestRouter(t)
+	insertProduct(t, router, 1)
+
+	tests := []struct {
+		query string
+		page  uint32
+		limit uint32
+		items int
+	}{
+		{"", 1, 10, 1},
+		{"?limit=4294967297", 1, usecases.MaxListLimit, 1},
+		{"?page=4294967297&limit=5", 4294967295, 5, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			code, res := do(t, router, http.MethodGet, "/product/"+tt.query, "", "", nil)
+			if code != http.StatusOK {
+				t.Fatalf("status = %d, body %s", code, res.Data)
+			}
+
+			var list model.ProductListRes
+			if err := json.NewDecoder(bytes.NewReader(res.Data)).Decode(&list); err != nil {
+				t.Fatalf("decode list: %v", err)
+			}
+			if list.Meta.CurrentPage != tt.page || list.Meta.Limit != tt.limit || len(list.Items) != tt.items {
+				t.Fatalf("meta %+v with %d items, want page %d limit %d and %d items", *list.Meta, len(list.Items), tt.page, tt.limit, tt.items)
+			}
+		})
+	}
+}
@@ -1,10 +1,11 @@
 package handlers
 
 import (
-	"fmt"
 	"net/http"
 
 	"github.com/Reza1878/goesclearning/user-service/helper/fault"
+	"github.com/Reza1878/goesclearning/user-service/helper/jwt"
+	"github.com/Reza1878/goesclearning/user-service/helper/logger"
 	"github.com/Reza1878/goesclearning/user-service/helper/response"
 	"github.com/Reza1878/goesclearning/user-service/model"
 	usecases "github.com/Reza1878/goesclearning/user-service/usecases/user"
@@ -26,40 +27,148 @@ func (h *Handler) HandleUserRegister(ctx *gin.Context) {
 	var body model.RegisterUser
 
 	if err := ctx.ShouldBindJSON(&body); err != nil {
-		fault.ErrorHandler(ctx, fault.Custom(
-			http.StatusBadRequest,
-			fault.ErrBadRequest,
-			fmt.Sprintf("failed to bind JSON: %v", err),
-		))
+		fault.ErrorHandler(ctx, fault.Validation(err))
 		return
 	}
 
-	bRes, err := h.user.UserRegister(body)
+	bRes, err := h.user.UserRegister(body, clientInfo(ctx))
 	if err != nil {
 		fault.ErrorHandler(ctx, err)
 		return
 	}
+	logger.SetUserID(ctx, bRes.UserData.Id.String())
 
-	response.JSON(ctx, http.StatusAccepted, "Success", bRes)
+	response.JSON(ctx, http.StatusAccepted, response.MsgSuccess, bRes)
 }
 
 func (h *Handler) HandleUserLogin(ctx *gin.Context) {
 	var body model.LoginRequest
 
 	if err := ctx.ShouldBindBodyWithJSON(&body); err != nil {
-		fault.ErrorHandler(ctx, fault.Custom(
+		fault.ErrorHandler(ctx, fault.Validation(err))
+		return
+	}
+
+	bRes, err := h.user.UserLogin(body, clientInfo(ctx))
+	if err != nil {
+		fault.ErrorHandler(ctx, err)
+		return
+	}
+	logger.SetUserID(ctx, bRes.UserData.Id.String())
+
+	response.JSON(ctx, http.StatusAccepted, response.MsgSuccess, bRes)
+}
+
+func (h *Handler) HandleRefresh(ctx *gin.Context) {
+	var body model.RefreshRequest
+
+	if err := ctx.ShouldBindJSON(&body); err != nil {
+		fault.ErrorHandler(ctx, fault.Validation(err))
+		return
+	}
+
+	bRes, err := h.user.RefreshSession(body.RefreshToken, clientInfo(ctx))
+	if err != nil {
+		fault.ErrorHandler(ctx, err)
+		return
+	}
+	logger.SetUserID(ctx, bRes.UserData.Id.String())
+
+	response.JSON(ctx, http.StatusOK, response.MsgSuccess, bRes)
+}
+
+func (h *Handler) HandleUsernameAvailable(ctx *gin.Context) {
+	name := ctx.Query("u")
+	if name == "" {
+		fault.Response(ctx, fault.Custom(
 			http.StatusBadRequest,
 			fault.ErrBadRequest,
-			fmt.Sprintf("failed to bind JSON: %v", err),
-		))
+			"missing username query parameter",
+		).WithDetails(fault.FieldError("u", "required", "")))
+		return
+	}
+
+	res, err := h.user.UsernameAvailable(name)
+	if err != nil {
+		fault.ErrorHandler(ctx, err)
+		return
+	}
+
+	response.JSON(ctx, http.StatusOK, response.MsgSuccess, res)
+}
+
+func (h *Handler) HandleListSessions(ctx *gin.Context) {
+	claims, err := jwt.ClaimsFromContext(ctx)
+	if err != nil {
+		fault.Response(ctx, err)
+		return
+	}
+
+	sessions, err := h.user.ListSessions(claims)
+	if err != nil {
+		fault.ErrorHandler(ctx, err)
+		return
+	}
+
+	response.JSON(ctx, http.StatusOK, response.MsgSuccess, sessions)
+}
+
+func (h *Handler) HandleRevokeSession(ctx *gin.Context) {
+	claims, err := jwt.ClaimsFromContext(ctx)
+	if err != nil {
+		fault.Response(ctx, err)
+		return
+	}
+
+	if err := h.user.RevokeSession(claims, ctx.Param("id"), clientInfo(ctx)); err != nil {
+		fault.ErrorHandler(ctx, err)
+		return
+	}
+
+	response.JSON(ctx, http.StatusOK, response.MsgSuccess, nil)
+}
+
+func (h *Handler) HandleSecurityEvents(ctx *gin.Context) {
+	claims, err := jwt.ClaimsFromContext(ctx)
+	if err != nil {
+		fault.Response(ctx, err)
+		return
+	}
+
+	var query model.LoginEventQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		fault.Response(ctx, fault.Validation(err))
+		return
+	}
+
+	res, err := h.user.ListSecurityEvents(claims, query)
+	if err != nil {
+		fault.ErrorHandler(ctx, err)
 		return
 	}
 
-	bRes, err := h.user.UserLogin(body)
+	response.JSON(ctx, http.StatusOK, response.MsgSuccess, res)
+}
+
+func (h *Handler) HandleAdminSecurityEvents(ctx *gin.Context) {
+	var query model.LoginEventQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		fault.Response(ctx, fault.Validation(err))
+		return
+	}
+
+	res, err := h.user.ListAllSecurityEvents(query)
 	if err != nil {
 		fault.ErrorHandler(ctx, err)
 		return
 	}
 
-	response.JSON(ctx, http.StatusAccepted, "Success", bRes)
+	response.JSON(ctx, http.StatusOK, response.MsgSuccess, res)
+}
+
+func clientInfo(ctx *gin.Context) model.ClientInfo {
+	return model.ClientInfo{
+		UserAgent: ctx.Request.UserAgent(),
+		IP:        ctx.ClientIP(),
+	}
 }
@@ -0,0 +1,199 @@
+// Package audit keeps an append-only, hash-chained record of
+// administrative and security-sensitive actions. Each entry stores the
+// hash of the entry before it, so editing or removing any entry breaks
+// every hash after it; Verify walks the chain to detect that.
+package audit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Reza1878/goesclearning/user-service/helper/logger"
+)
+
+// Actions. user.role_change, user.update and user.unlock are reserved for
+// the admin user flows that will emit them. Entries are written before
+// the change is made; a *_failed entry follows when the change did not
+// go through.
+const (
+	ActionUserRoleChange      = "user.role_change"
+	ActionUserUpdate          = "user.update"
+	ActionUserUnlock          = "user.unlock"
+	ActionProductUpdate       = "product.update"
+	ActionProductUpdateFailed = "product.update_failed"
+	ActionProductDelete       = "product.delete"
+	ActionProductDeleteFailed = "product.delete_failed"
+)
+
+const (
+	TargetUser    = "user"
+	TargetProduct = "product"
+)
+
+// GenesisHash is the previous hash of the first entry.
+var GenesisHash = strings.Repeat("0", sha256.Size*2)
+
+type Entry struct {
+	Seq        int64  `json:"seq"`
+	ActorId    string `json:"actor_id"`
+	ActorRole  string `json:"actor_role,omitempty"`
+	Action     string `json:"action"`
+	TargetType string `json:"target_type"`
+	TargetId   string `json:"target_id"`
+	// Changes maps each changed field to its before and after value. It
+	// is hashed byte for byte, so it is stored verbatim.
+	Changes   json.RawMessage `json:"changes,omitempty"`
+	RequestId string          `json:"request_id,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+	PrevHash  string          `json:"prev_hash"`
+	Hash      string          `json:"hash"`
+}
+
+// Event is an action to record. Before and After are the target's state
+// around the action; either may be nil.
+type Event struct {
+	ActorId    string
+	ActorRole  string
+	Action     string
+	TargetType string
+	TargetId   string
+	Before     any
+	After      any
+}
+
+type Filter struct {
+	ActorId    string
+	Action     string
+	TargetType string
+	TargetId   string
+	From       *time.Time
+	To         *time.Time
+	Page       int
+	Limit      int
+}
+
+type Store interface {
+	// Append chains e to the current head and stores it. Appends are
+	// serialised so the chain never forks.
+	Append(ctx context.Context, e Entry) (*Entry, error)
+	// List returns one page of entries, newest first, and the number of
+	// entries matching the filter.
+	List(ctx context.Context, filter Filter) ([]Entry, int, error)
+	// Walk calls fn for every entry in chain order.
+	Walk(ctx context.Context, fn func(Entry) error) error
+}
+
+type Log struct {
+	store Store
+}
+
+func New(store Store) *Log {
+	return &Log{store: store}
+}
+
+// Record appends the event, stamped with the request ID of ctx.
+func (l *Log) Record(ctx context.Context, ev Event) (*Entry, error) {
+	changes, err := Diff(ev.Before, ev.After)
+	if err != nil {
+		return nil, err
+	}
+
+	return l.store.Append(ctx, Entry{
+		ActorId:    ev.ActorId,
+		ActorRole:  ev.ActorRole,
+		Action:     ev.Action,
+		TargetType: ev.TargetType,
+		TargetId:   ev.TargetId,
+		Changes:    changes,
+		RequestId:  logger.RequestID(ctx),
+		CreatedAt:  time.Now(),
+	})
+}
+
+func (l *Log) List(ctx context.Context, filter Filter) ([]Entry, int, error) {
+	return l.store.List(ctx, filter)
+}
+
+// seal links e to the entry with the given sequence number and hash.
+func seal(e Entry, prevSeq int64, prevHash string) Entry {
+	e.Seq = prevSeq + 1
+	e.PrevHash = prevHash
+	// stores keep microseconds, so hash what will be read back
+	e.CreatedAt = e.CreatedAt.UTC().Truncate(time.Microsecond)
+	e.Hash = hash(e)
+	return e
+}
+
+// hash covers every field but Hash itself, in a fixed order.
+func hash(e Entry) string {
+	fields := []string{
+		fmt.Sprint(e.Seq),
+		e.ActorId,
+		e.ActorRole,
+		e.Action,
+		e.TargetType,
+		e.TargetId,
+		string(e.Changes),
+		e.RequestId,
+		e.CreatedAt.UTC().Format(time.RFC3339Nano),
+		e.PrevHash,
+	}
+
+	h := sha256.New()
+	for _, field := range fields {
+		// length-prefixed so field boundaries cannot shift
+		fmt.Fprintf(h, "%d:%s;", len(field), field)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Report is the outcome of Verify.
+type Report struct {
+	Entries int64
+	// Head is the hash of the last entry. Keeping it somewhere outside
+	// the database also makes removal of the newest entries detectable.
+	Head string
+	// BrokenAt is the first entry that does not verify, with Reason
+	// saying why; zero when the chain is intact.
+	BrokenAt int64
+	Reason   string
+}
+
+func (r *Report) OK() bool {
+	return r.BrokenAt == 0
+}
+
+// Verify walks the whole chain and reports the first entry whose
+// sequence number, link or hash does not match.
+func Verify(ctx context.Context, store Store) (*Report, error) {
+	report := &Report{Head: GenesisHash}
+
+	err := store.Walk(ctx, func(e Entry) error {
+		if !report.OK() {
+			return nil
+		}
+
+		switch {
+		case e.Seq != report.Entries+1:
+			report.BrokenAt, report.Reason = e.Seq, fmt.Sprintf("expected sequence %d, entries are missing", report.Entries+1)
+		case e.PrevHash != report.Head:
+			report.BrokenAt, report.Reason = e.Seq, "previous hash does not match the preceding entry"
+		case e.Hash != hash(e):
+			report.BrokenAt, report.Reason = e.Seq, "entry content does not match its hash"
+		default:
+			report.Entries++
+			report.Head = e.Hash
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
@@ -0,0 +1,131 @@
+package audit
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+// chain records n entries and returns the store holding them.
+func chain(t *testing.T, n int) *memoryStore {
+	t.Helper()
+
+	store := NewMemory().(*memoryStore)
+	log := New(store)
+	for i := 0; i < n; i++ {
+		_, err := log.Record(context.Background(), Event{
+			ActorId:    "admin",
+			ActorRole:  "admin",
+			Action:     ActionProductUpdate,
+			TargetType: TargetProduct,
+			TargetId:   "p1",
+			Before:     map[string]int{"qty": i},
+			After:      map[string]int{"qty": i + 1},
+		})
+		if err != nil {
+			t.Fatalf("Record: %v", err)
+		}
+	}
+	return store
+}
+
+func TestVerify(t *testing.T) {
+	tests := []struct {
+		name       string
+		tamper     func(entries []Entry) []Entry
+		wantBroken int64
+		wantReason string
+	}{
+		{
+			name:   "intact",
+			tamper: func(entries []Entry) []Entry { return entries },
+		},
+		{
+			name: "tampered field",
+			tamper: func(entries []Entry) []Entry {
+				entries[1].ActorId = "someone-else"
+				return entries
+			},
+			wantBroken: 2,
+			wantReason: "entry content does not match its hash",
+		},
+		{
+			name: "tampered field with rehash",
+			tamper: func(entries []Entry) []Entry {
+				entries[1].Changes = []byte(`{"qty":{"before":0,"after":1000}}`)
+				entries[1].Hash = hash(entries[1])
+				return entries
+			},
+			wantBroken: 3,
+			wantReason: "previous hash does not match the preceding entry",
+		},
+		{
+			name: "missing seq",
+			tamper: func(entries []Entry) []Entry {
+				return append(entries[:1], entries[2:]...)
+			},
+			wantBroken: 3,
+			wantReason: "expected sequence 2, entries are missing",
+		},
+		{
+			name: "reordered entries",
+			tamper: func(entries []Entry) []Entry {
+				entries[1], entries[2] = entries[2], entries[1]
+				return entries
+			},
+			wantBroken: 3,
+			wantReason: "expected sequence 2",
+		},
+		{
+			name: "reordered and renumbered",
+			tamper: func(entries []Entry) []Entry {
+				entries[1], entries[2] = entries[2], entries[1]
+				entries[1].Seq, entries[2].Seq = 2, 3
+				return entries
+			},
+			wantBroken: 2,
+			wantReason: "previous hash does not match the preceding entry",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := chain(t, 4)
+			head := store.entries[len(store.entries)-1].Hash
+			store.entries = tt.tamper(store.entries)
+
+			report, err := Verify(context.Background(), store)
+			if err != nil {
+				t.Fatalf("Verify: %v", err)
+			}
+
+			if report.BrokenAt != tt.wantBroken {
+				t.Fatalf("BrokenAt = %d (%s), want %d", report.BrokenAt, report.Reason, tt.wantBroken)
+			}
+			if !strings.Contains(report.Reason, tt.wantReason) {
+				t.Fatalf("Reason = %q, want %q", report.Reason, tt.wantReason)
+			}
+			if tt.wantBroken == 0 && (report.Entries != 4 || report.Head != head) {
+				t.Fatalf("report = %+v, want 4 entries ending at %s", report, head)
+			}
+		})
+	}
+}
+
+func TestSealLinksEntries(t *testing.T) {
+	store := chain(t, 2)
+	first, second := store.entries[0], store.entries[1]
+
+	if first.Seq != 1 || first.PrevHash != GenesisHash {
+		t.Fatalf("first entry = seq %d prev %s, want seq 1 after the genesis hash", first.Seq, first.PrevHash)
+	}
+	if second.Seq != 2 || second.PrevHash != first.Hash {
+		t.Fatalf("second entry = seq %d prev %s, want seq 2 after %s", second.Seq, second.PrevHash, first.Hash)
+	}
+	if first.CreatedAt.Location().String() != "UTC" || first.CreatedAt.Nanosecond()%1000 != 0 {
+		t.Fatalf("created_at %v is not UTC microseconds", first.CreatedAt)
+	}
+	if first.Hash != hash(first) {
+		t.Fatalf("stored hash does not match the entry")
+	}
+}
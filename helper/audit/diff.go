@@ -0,0 +1,105 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// redacted fields are recorded as changed without their values.
+var redacted = map[string]bool{
+	"password": true,
+}
+
+var redactedValue = json.RawMessage(`"[redacted]"`)
+
+type Change struct {
+	Before json.RawMessage `json:"before,omitempty"`
+	After  json.RawMessage `json:"after,omitempty"`
+}
+
+// Diff compares the top-level JSON fields of before and after and returns
+// the changed ones as a JSON object keyed by field name, or nil when
+// nothing changed. Protobuf messages are compared in their proto JSON
+// form.
+func Diff(before, after any) (json.RawMessage, error) {
+	old, err := fields(before)
+	if err != nil {
+		return nil, err
+	}
+	current, err := fields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := map[string]Change{}
+	for name, value := range old {
+		if !bytes.Equal(value, current[name]) {
+			changes[name] = Change{Before: value, After: current[name]}
+		}
+	}
+	for name, value := range current {
+		if _, ok := old[name]; !ok {
+			changes[name] = Change{After: value}
+		}
+	}
+
+	if len(changes) == 0 {
+		return nil, nil
+	}
+
+	for name, change := range changes {
+		if redacted[name] {
+			changes[name] = Change{Before: redact(change.Before), After: redact(change.After)}
+		}
+	}
+
+	// map keys are marshalled in sorted order, so this is deterministic
+	raw, err := json.Marshal(changes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode audit changes: %w", err)
+	}
+	return raw, nil
+}
+
+func fields(v any) (map[string]json.RawMessage, error) {
+	if v == nil {
+		return map[string]json.RawMessage{}, nil
+	}
+
+	var raw []byte
+	var err error
+	if msg, ok := v.(proto.Message); ok {
+		raw, err = protojson.MarshalOptions{UseProtoNames: true}.Marshal(msg)
+	} else {
+		raw, err = json.Marshal(v)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode audit state: %w", err)
+	}
+
+	var out map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &out); err != nil {
+		return nil, fmt.Errorf("audit state is not a JSON object: %w", err)
+	}
+
+	// protojson output is not byte-stable, so normalise every value
+	for name, value := range out {
+		var buf bytes.Buffer
+		if err := json.Compact(&buf, value); err != nil {
+			return nil, fmt.Errorf("failed to compact audit field %s: %w", name, err)
+		}
+		out[name] = buf.Bytes()
+	}
+	return out, nil
+}
+
+func redact(value json.RawMessage) json.RawMessage {
+	if value == nil {
+		return nil
+	}
+	return redactedValue
+}
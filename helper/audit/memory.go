@@ -0,0 +1,76 @@
+package audit
+
+import (
+	"context"
+	"sync"
+)
+
+// memoryStore keeps the chain in process, for development and tests.
+type memoryStore struct {
+	mu      sync.RWMutex
+	entries []Entry
+}
+
+func NewMemory() Store {
+	return &memoryStore{}
+}
+
+func (s *memoryStore) Append(_ context.Context, e Entry) (*Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var prevSeq int64
+	prevHash := GenesisHash
+	if n := len(s.entries); n > 0 {
+		prevSeq, prevHash = s.entries[n-1].Seq, s.entries[n-1].Hash
+	}
+
+	e = seal(e, prevSeq, prevHash)
+	s.entries = append(s.entries, e)
+	return &e, nil
+}
+
+func (s *memoryStore) List(_ context.Context, filter Filter) ([]Entry, int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	matched := []Entry{}
+	for i := len(s.entries) - 1; i >= 0; i-- {
+		e := s.entries[i]
+		switch {
+		case filter.ActorId != "" && e.ActorId != filter.ActorId,
+			filter.Action != "" && e.Action != filter.Action,
+			filter.TargetType != "" && e.TargetType != filter.TargetType,
+			filter.TargetId != "" && e.TargetId != filter.TargetId,
+			filter.From != nil && e.CreatedAt.Before(*filter.From),
+			filter.To != nil && !e.CreatedAt.Before(*filter.To):
+			continue
+		}
+		matched = append(matched, e)
+	}
+
+	start := (filter.Page - 1) * filter.Limit
+	if start > len(matched) {
+		start = len(matched)
+	}
+	end := start + filter.Limit
+	if end > len(matched) {
+		end = len(matched)
+	}
+
+	return matched[start:end], len(matched), nil
+}
+
+func (s *memoryStore) Walk(_ context.Context, fn func(Entry) error) error {
+	s.mu.RLock()
+	entries := make([]Entry, len(s.entries))
+	copy(entries, s.entries)
+	s.mu.RUnlock()
+
+	for _, e := range entries {
+		if err := fn(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,171 @@
+package audit
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/Reza1878/goesclearning/user-service/helper/fault"
+)
+
+// appendLockKey is the advisory lock that serialises appends across
+// every instance sharing the database.
+const appendLockKey = 0x61756469
+
+type postgresStore struct {
+	db *sql.DB
+}
+
+func NewPostgres(db *sql.DB) Store {
+	return &postgresStore{db: db}
+}
+
+const entryColumns = `seq, actor_id, actor_role, action, target_type, target_id, changes, request_id, created_at, prev_hash, hash`
+
+func (s *postgresStore) Append(ctx context.Context, e Entry) (*Entry, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, storeError("failed to start audit transaction", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1)`, appendLockKey); err != nil {
+		return nil, storeError("failed to lock audit log", err)
+	}
+
+	var prevSeq int64
+	prevHash := GenesisHash
+	err = tx.QueryRowContext(ctx, `SELECT seq, hash FROM audit_log ORDER BY seq DESC LIMIT 1`).Scan(&prevSeq, &prevHash)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, storeError("failed to read audit log head", err)
+	}
+
+	e = seal(e, prevSeq, prevHash)
+
+	_, err = tx.ExecContext(ctx, `INSERT INTO audit_log(`+entryColumns+`)
+		VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`,
+		e.Seq, e.ActorId, e.ActorRole, e.Action, e.TargetType, e.TargetId,
+		string(e.Changes), e.RequestId, e.CreatedAt, e.PrevHash, e.Hash,
+	)
+	if err != nil {
+		return nil, storeError(fmt.Sprintf("failed to append audit entry %s", e.Action), err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, storeError("failed to commit audit entry", err)
+	}
+
+	return &e, nil
+}
+
+func (s *postgresStore) List(ctx context.Context, filter Filter) ([]Entry, int, error) {
+	var args []interface{}
+	var conditions []string
+
+	argPos := 1
+	add := func(condition string, value interface{}) {
+		conditions = append(conditions, fmt.Sprintf(condition, argPos))
+		args = append(args, value)
+		argPos++
+	}
+
+	if filter.ActorId != "" {
+		add("actor_id = $%d", filter.ActorId)
+	}
+	if filter.Action != "" {
+		add("action = $%d", filter.Action)
+	}
+	if filter.TargetType != "" {
+		add("target_type = $%d", filter.TargetType)
+	}
+	if filter.TargetId != "" {
+		add("target_id = $%d", filter.TargetId)
+	}
+	if filter.From != nil {
+		add("created_at >= $%d", filter.From.UTC())
+	}
+	if filter.To != nil {
+		add("created_at < $%d", filter.To.UTC())
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int
+	if err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM audit_log`+where, args...).Scan(&total); err != nil {
+		return nil, 0, storeError("failed to count audit entries", err)
+	}
+
+	query := `SELECT ` + entryColumns + ` FROM audit_log` + where +
+		fmt.Sprintf(" ORDER BY seq DESC LIMIT $%d OFFSET $%d", argPos, argPos+1)
+	args = append(args, filter.Limit, (filter.Page-1)*filter.Limit)
+
+	entries := []Entry{}
+	err := s.query(ctx, query, args, func(e Entry) error {
+		entries = append(entries, e)
+		return nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return entries, total, nil
+}
+
+func (s *postgresStore) Walk(ctx context.Context, fn func(Entry) error) error {
+	return s.query(ctx, `SELECT `+entryColumns+` FROM audit_log ORDER BY seq`, nil, fn)
+}
+
+func (s *postgresStore) query(ctx context.Context, query string, args []interface{}, fn func(Entry) error) error {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return storeError("failed to read audit log", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var e Entry
+		var changes string
+		err := rows.Scan(
+			&e.Seq,
+			&e.ActorId,
+			&e.ActorRole,
+			&e.Action,
+			&e.TargetType,
+			&e.TargetId,
+			&changes,
+			&e.RequestId,
+			&e.CreatedAt,
+			&e.PrevHash,
+			&e.Hash,
+		)
+		if err != nil {
+			return storeError("failed to read audit entry", err)
+		}
+		if changes != "" {
+			e.Changes = []byte(changes)
+		}
+
+		if err := fn(e); err != nil {
+			return err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return storeError("failed to read audit log", err)
+	}
+	return nil
+}
+
+func storeError(msg string, err error) error {
+	return fault.Custom(
+		http.StatusInternalServerError,
+		fault.ErrInternalServer,
+		fmt.Sprintf("%s: %v", msg, err),
+	)
+}
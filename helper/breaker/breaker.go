@@ -0,0 +1,166 @@
+package breaker
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+type State int
+
+const (
+	Closed State = iota
+	Open
+	HalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case Closed:
+		return "closed"
+	case Open:
+		return "open"
+	case HalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+type Config struct {
+	// FailureThreshold is the number of consecutive failures that opens
+	// the breaker.
+	FailureThreshold int
+	// OpenTimeout is how long the breaker stays open before it lets probe
+	// calls through.
+	OpenTimeout time.Duration
+	// HalfOpenProbes is the number of concurrent probes allowed while
+	// half-open, and the number of successes needed to close again.
+	HalfOpenProbes int
+}
+
+type OpenError struct {
+	Name       string
+	RetryAfter time.Duration
+}
+
+func (e *OpenError) Error() string {
+	return fmt.Sprintf("circuit breaker %s is open, retry after %s", e.Name, e.RetryAfter)
+}
+
+type Breaker struct {
+	name string
+	cfg  Config
+	now  func() time.Time
+
+	mu        sync.Mutex
+	state     State
+	failures  int
+	openedAt  time.Time
+	probes    int
+	successes int
+
+	onStateChange func(name string, from, to State)
+}
+
+func New(name string, cfg Config) *Breaker {
+	if cfg.FailureThreshold < 1 {
+		cfg.FailureThreshold = 1
+	}
+	if cfg.HalfOpenProbes < 1 {
+		cfg.HalfOpenProbes = 1
+	}
+
+	return &Breaker{
+		name: name,
+		cfg:  cfg,
+		now:  time.Now,
+	}
+}
+
+func (b *Breaker) OnStateChange(fn func(name string, from, to State)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.onStateChange = fn
+}
+
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+// Allow reports whether a call may proceed. Every nil return must be
+// followed by exactly one Record with the outcome of the call.
+func (b *Breaker) Allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case Open:
+		remaining := b.openedAt.Add(b.cfg.OpenTimeout).Sub(b.now())
+		if remaining > 0 {
+			return &OpenError{Name: b.name, RetryAfter: remaining}
+		}
+		b.setState(HalfOpen)
+		b.probes = 1
+		return nil
+
+	case HalfOpen:
+		if b.probes >= b.cfg.HalfOpenProbes {
+			return &OpenError{Name: b.name, RetryAfter: time.Second}
+		}
+		b.probes++
+		return nil
+
+	default:
+		return nil
+	}
+}
+
+func (b *Breaker) Record(success bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case Closed:
+		if success {
+			b.failures = 0
+			return
+		}
+		b.failures++
+		if b.failures >= b.cfg.FailureThreshold {
+			b.trip()
+		}
+
+	case HalfOpen:
+		if b.probes > 0 {
+			b.probes--
+		}
+		if !success {
+			b.trip()
+			return
+		}
+		b.successes++
+		if b.successes >= b.cfg.HalfOpenProbes {
+			b.setState(Closed)
+		}
+	}
+}
+
+func (b *Breaker) trip() {
+	b.setState(Open)
+	b.openedAt = b.now()
+}
+
+func (b *Breaker) setState(state State) {
+	from := b.state
+	b.state = state
+	b.failures = 0
+	b.probes = 0
+	b.successes = 0
+
+	if from != state && b.onStateChange != nil {
+		b.onStateChange(b.name, from, state)
+	}
+}
@@ -2,37 +2,50 @@ package cache
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
-	"net/http"
 	"time"
-
-	"github.com/Reza1878/goesclearning/user-service/helper/fault"
-	"github.com/redis/go-redis/v9"
 )
 
-func Set(ctx context.Context, client *redis.Client, key string, value interface{}, ttl time.Duration) error {
-	err := client.Set(ctx, key, value, ttl).Err()
-	if err != nil {
-		return fault.Custom(
-			http.StatusUnprocessableEntity,
-			fault.ErrUnprocessable,
-			fmt.Sprintf("failed to save to Redis [key=%s]: %v", key, err))
-	}
-	return nil
+// ErrMiss is returned by Get when the key does not exist or has expired.
+var ErrMiss = errors.New("cache: miss")
+
+// Store is a byte-oriented key/value cache. A zero TTL keeps the key
+// until it is deleted or evicted.
+type Store interface {
+	Get(ctx context.Context, key string) ([]byte, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// SetNX sets the key only when it does not exist and reports whether
+	// it did.
+	SetNX(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error)
+	Exist(ctx context.Context, key string) (bool, error)
+	Delete(ctx context.Context, keys ...string) error
+	// Incr adds one to the integer stored at key, starting from zero, and
+	// keeps the key's TTL.
+	Incr(ctx context.Context, key string) (int64, error)
 }
 
-func Exist(ctx context.Context, client *redis.Client, key string) (bool, error) {
-	count, err := client.Exists(ctx, key).Result()
+// GetJSON reads and decodes a value stored by SetJSON.
+func GetJSON[T any](ctx context.Context, store Store, key string) (T, error) {
+	var value T
+
+	raw, err := store.Get(ctx, key)
 	if err != nil {
-		return false, err
+		return value, err
 	}
-	return count > 0, nil
+
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return value, fmt.Errorf("failed to decode cached value [key=%s]: %w", key, err)
+	}
+	return value, nil
 }
 
-func Get(ctx context.Context, client *redis.Client, key string) (interface{}, error) {
-	value, err := client.Get(ctx, key).Result()
+// SetJSON encodes value as JSON and stores it.
+func SetJSON(ctx context.Context, store Store, key string, value any, ttl time.Duration) error {
+	raw, err := json.Marshal(value)
 	if err != nil {
-		return nil, err
+		return fmt.Errorf("failed to encode value for cache [key=%s]: %w", key, err)
 	}
-	return value, nil
+	return store.Set(ctx, key, raw, ttl)
 }
@@ -0,0 +1,178 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// newTestMemory returns a memory store on a clock the test moves by hand.
+func newTestMemory(maxEntries int) (*memoryStore, func(time.Duration)) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	s := NewMemory(maxEntries).(*memoryStore)
+	s.now = func() time.Time { return now }
+	return s, func(d time.Duration) { now = now.Add(d) }
+}
+
+func value(t *testing.T, s Store, key string) string {
+	t.Helper()
+
+	raw, err := s.Get(context.Background(), key)
+	if errors.Is(err, ErrMiss) {
+		return ""
+	}
+	if err != nil {
+		t.Fatalf("Get(%s): %v", key, err)
+	}
+	return string(raw)
+}
+
+func TestMemoryEviction(t *testing.T) {
+	tests := []struct {
+		name  string
+		touch []string
+		want  map[string]string
+	}{
+		{"oldest goes first", nil, map[string]string{"a": "", "b": "2", "c": "3"}},
+		{"a read keeps a key", []string{"a"}, map[string]string{"a": "1", "b": "", "c": "3"}},
+		{"an overwrite keeps a key", []string{"set:a"}, map[string]string{"a": "1", "b": "", "c": "3"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			s, _ := newTestMemory(2)
+
+			s.Set(ctx, "a", []byte("1"), 0)
+			s.Set(ctx, "b", []byte("2"), 0)
+			for _, key := range tt.touch {
+				if key == "set:a" {
+					s.Set(ctx, "a", []byte("1"), 0)
+					continue
+				}
+				s.Get(ctx, key)
+			}
+			s.Set(ctx, "c", []byte("3"), 0)
+
+			for key, want := range tt.want {
+				if got := value(t, s, key); got != want {
+					t.Errorf("%s = %q, want %q", key, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestMemoryTTL(t *testing.T) {
+	tests := []struct {
+		name    string
+		ttl     time.Duration
+		elapsed time.Duration
+		want    string
+	}{
+		{"before expiry", time.Minute, 59 * time.Second, "v"},
+		{"at expiry", time.Minute, time.Minute, ""},
+		{"zero ttl keeps the key", 0, 24 * time.Hour, "v"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			s, advance := newTestMemory(0)
+
+			s.Set(ctx, "k", []byte("v"), tt.ttl)
+			advance(tt.elapsed)
+
+			if got := value(t, s, "k"); got != tt.want {
+				t.Fatalf("value = %q, want %q", got, tt.want)
+			}
+			exists, _ := s.Exist(ctx, "k")
+			if exists != (tt.want != "") {
+				t.Fatalf("Exist = %v, want %v", exists, tt.want != "")
+			}
+		})
+	}
+}
+
+func TestMemorySetNX(t *testing.T) {
+	tests := []struct {
+		name    string
+		elapsed time.Duration
+		want    bool
+		value   string
+	}{
+		{"live key", 30 * time.Second, false, "first"},
+		{"expired key", time.Minute, true, "second"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			s, advance := newTestMemory(0)
+
+			if ok, _ := s.SetNX(ctx, "k", []byte("first"), time.Minute); !ok {
+				t.Fatalf("SetNX on a missing key reported it as taken")
+			}
+			advance(tt.elapsed)
+
+			ok, err := s.SetNX(ctx, "k", []byte("second"), time.Minute)
+			if err != nil || ok != tt.want {
+				t.Fatalf("SetNX = %v, %v, want %v", ok, err, tt.want)
+			}
+			if got := value(t, s, "k"); got != tt.value {
+				t.Fatalf("value = %q, want %q", got, tt.value)
+			}
+		})
+	}
+}
+
+func TestMemoryIncr(t *testing.T) {
+	ctx := context.Background()
+	s, advance := newTestMemory(0)
+
+	if n, err := s.Incr(ctx, "missing"); err != nil || n != 1 {
+		t.Fatalf("Incr on a missing key = %d, %v, want 1", n, err)
+	}
+
+	s.Set(ctx, "n", []byte("41"), time.Minute)
+	advance(30 * time.Second)
+	if n, err := s.Incr(ctx, "n"); err != nil || n != 42 {
+		t.Fatalf("Incr = %d, %v, want 42", n, err)
+	}
+
+	// Incr kept the expiry set by Set, it did not restart or clear it
+	advance(30 * time.Second)
+	if got := value(t, s, "n"); got != "" {
+		t.Fatalf("value after the original expiry = %q, want a miss", got)
+	}
+
+	// a key without a TTL stays without one
+	advance(24 * time.Hour)
+	if got := value(t, s, "missing"); got != "1" {
+		t.Fatalf("key without a TTL = %q, want 1", got)
+	}
+
+	s.Set(ctx, "text", []byte("abc"), 0)
+	if _, err := s.Incr(ctx, "text"); err == nil {
+		t.Fatalf("Incr on a non-integer succeeded")
+	}
+}
+
+func TestMemoryDelete(t *testing.T) {
+	ctx := context.Background()
+	s, _ := newTestMemory(0)
+
+	s.Set(ctx, "a", []byte("1"), 0)
+	s.Set(ctx, "b", []byte("2"), 0)
+	if err := s.Delete(ctx, "a", "missing"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+
+	if got := value(t, s, "a"); got != "" {
+		t.Fatalf("deleted key = %q, want a miss", got)
+	}
+	if got := value(t, s, "b"); got != "2" {
+		t.Fatalf("other key = %q, want 2", got)
+	}
+}
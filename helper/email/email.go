@@ -0,0 +1,68 @@
+package email
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+
+	"golang.org/x/net/idna"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Folding controls how the local part (before the @) is normalised. The
+// domain is always lowercased and converted to its ASCII (IDNA) form.
+type Folding string
+
+const (
+	// FoldNone keeps the local part as typed. Uniqueness is still
+	// case-insensitive because the column is citext.
+	FoldNone Folding = "none"
+	// FoldLower lowercases the local part.
+	FoldLower Folding = "lower"
+	// FoldSubaddress lowercases the local part and drops a +tag, so
+	// alice+shop@example.com is alice@example.com.
+	FoldSubaddress Folding = "subaddress"
+)
+
+var ErrInvalid = errors.New("invalid email address")
+
+var folding atomic.Value
+
+func init() {
+	folding.Store(FoldLower)
+}
+
+// SetFolding changes the local part folding for every later Normalize.
+func SetFolding(f Folding) {
+	folding.Store(f)
+}
+
+// Normalize returns the form an address is stored and looked up in, so
+// that Alice@Example.COM and alice@example.com are the same account.
+func Normalize(address string) (string, error) {
+	address = norm.NFC.String(strings.TrimSpace(address))
+
+	at := strings.Index(address, "@")
+	if at <= 0 || at == len(address)-1 || strings.Contains(address[at+1:], "@") {
+		return "", fmt.Errorf("%w: %q", ErrInvalid, address)
+	}
+	local, domain := address[:at], address[at+1:]
+
+	domain, err := idna.Lookup.ToASCII(strings.TrimSuffix(domain, "."))
+	if err != nil {
+		return "", fmt.Errorf("%w: domain of %q: %v", ErrInvalid, address, err)
+	}
+
+	switch folding.Load().(Folding) {
+	case FoldLower:
+		local = strings.ToLower(local)
+	case FoldSubaddress:
+		local = strings.ToLower(local)
+		if plus := strings.Index(local, "+"); plus > 0 {
+			local = local[:plus]
+		}
+	}
+
+	return local + "@" + strings.ToLower(domain), nil
+}
@@ -1,11 +1,16 @@
 package fault
 
 import (
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
+	"math"
 	"net/http"
+	"strconv"
+	"time"
 
-	"github.com/Reza1878/goesclearning/user-service/helper/response"
+	"github.com/Reza1878/goesclearning/user-service/helper/i18n"
+	"github.com/Reza1878/goesclearning/user-service/helper/logger"
 	"github.com/Reza1878/goesclearning/user-service/model"
 	"github.com/gin-gonic/gin"
 )
@@ -13,66 +18,75 @@ import (
 type ErrorCode string
 
 const (
-	ErrInternalServer ErrorCode = "INTERNAL_SERVER_ERROR"
-	ErrUnauthorized   ErrorCode = "UNAUTHORIZED"
-	ErrNotFound       ErrorCode = "NOT_FOUND"
-	ErrBadRequest     ErrorCode = "BAD_REQUEST"
-	ErrTimeout        ErrorCode = "TIMEOUT"
-	ErrConflict       ErrorCode = "CONFLICT"
-	ErrUnprocessable  ErrorCode = "UNPROCESSABLE_ENTITY"
-	ErrForbidden      ErrorCode = "FORBIDDEN"
-	ErrUnknown        ErrorCode = "UNKNOWN"
+	ErrInternalServer  ErrorCode = "INTERNAL_SERVER_ERROR"
+	ErrUnauthorized    ErrorCode = "UNAUTHORIZED"
+	ErrNotFound        ErrorCode = "NOT_FOUND"
+	ErrBadRequest      ErrorCode = "BAD_REQUEST"
+	ErrTimeout         ErrorCode = "TIMEOUT"
+	ErrConflict        ErrorCode = "CONFLICT"
+	ErrUnprocessable   ErrorCode = "UNPROCESSABLE_ENTITY"
+	ErrForbidden       ErrorCode = "FORBIDDEN"
+	ErrUnavailable     ErrorCode = "SERVICE_UNAVAILABLE"
+	ErrTooManyRequests ErrorCode = "TOO_MANY_REQUESTS"
+	ErrUnknown         ErrorCode = "UNKNOWN"
+
+	ErrEmailAlreadyRegistered ErrorCode = "EMAIL_ALREADY_REGISTERED"
+	ErrUsernameTaken          ErrorCode = "USERNAME_TAKEN"
+	ErrInvalidCredentials     ErrorCode = "INVALID_CREDENTIALS"
+	ErrInsufficientStock      ErrorCode = "INSUFFICIENT_STOCK"
+	ErrIdempotencyKeyReused   ErrorCode = "IDEMPOTENCY_KEY_REUSED"
 )
 
-type errorMessage string
-
-const (
-	msgInternalServer errorMessage = "An error occurred on the server. Please try again later."
-	msgUnauthorized   errorMessage = "You are not authorized to perform this action."
-	msgNotFound       errorMessage = "The requested data was not found."
-	msgBadRequest     errorMessage = "Invalid request. Please check the submitted data."
-	msgTimeout        errorMessage = "The request timed out. Please try again."
-	msgConflict       errorMessage = "The submitted data already exists or there is a conflict."
-	msgUnprocessable  errorMessage = "The request could not be processed."
-	msgForbidden      errorMessage = "You're not in the right place!"
-	msgUnknown        errorMessage = "An unknown error occurred."
-)
-
-var errorMessages = map[ErrorCode]errorMessage{
-	ErrInternalServer: msgInternalServer,
-	ErrUnauthorized:   msgUnauthorized,
-	ErrNotFound:       msgNotFound,
-	ErrBadRequest:     msgBadRequest,
-	ErrTimeout:        msgTimeout,
-	ErrConflict:       msgConflict,
-	ErrUnprocessable:  msgUnprocessable,
-	ErrForbidden:      msgForbidden,
-}
-
 type ErrorResponse struct {
 	HTTPStatus int    `json:"http_status"`
 	Message    string `json:"message"`
 }
 
 type DetailedError struct {
-	External ErrorResponse `json:"external"`
-	Internal ErrorResponse `json:"internal"`
+	Code       ErrorCode           `json:"code"`
+	External   ErrorResponse       `json:"external"`
+	Internal   ErrorResponse       `json:"internal"`
+	Details    []model.ErrorDetail `json:"details,omitempty"`
+	RetryAfter time.Duration       `json:"-"`
+}
+
+// problemJSON switches error bodies to RFC 7807 application/problem+json.
+var problemJSON bool
+
+func UseProblemJSON(enabled bool) {
+	problemJSON = enabled
 }
 
 func GetExternalMessage(code ErrorCode) string {
-	if msg, ok := errorMessages[code]; ok {
-		return (string(msg))
+	return LocalizedMessage(i18n.DefaultLocale, code)
+}
+
+func LocalizedMessage(locale string, code ErrorCode) string {
+	key := "error." + string(code)
+	if !i18n.Has(locale, key) {
+		key = "error." + string(ErrUnknown)
 	}
 
-	return string(msgUnknown)
+	return i18n.Translate(locale, key, nil)
 }
 
 func (e *DetailedError) Error() string {
 	return fmt.Sprintf("External: %s | Internal: %s", e.External.Message, e.Internal.Message)
 }
 
+func (e *DetailedError) WithDetails(details ...model.ErrorDetail) *DetailedError {
+	e.Details = append(e.Details, details...)
+	return e
+}
+
+func (e *DetailedError) WithRetryAfter(d time.Duration) *DetailedError {
+	e.RetryAfter = d
+	return e
+}
+
 func newError(httpStatus int, code ErrorCode, internalMessage string) *DetailedError {
 	return &DetailedError{
+		Code: code,
 		External: ErrorResponse{
 			HTTPStatus: httpStatus,
 			Message:    GetExternalMessage(code),
@@ -88,33 +102,76 @@ func Custom(httpStatus int, code ErrorCode, internalMessage string) *DetailedErr
 	return newError(httpStatus, code, internalMessage)
 }
 
-func ErrorHandler(ctx *gin.Context, err error) {
-	errors, ok := err.(*DetailedError)
-	if !ok {
-		errors = newError(http.StatusInternalServerError, "Something went wrong", err.Error())
+func asDetailedError(err error) *DetailedError {
+	var detailed *DetailedError
+	if errors.As(err, &detailed) {
+		return detailed
 	}
 
-	log.Println("[ERROR]: ", errors.Internal.Message)
-	ctx.JSON(
-		errors.External.HTTPStatus, model.ResponseError{
-			StatusCode: errors.External.HTTPStatus,
-			Message:    errors.External.Message,
-		},
+	return newError(http.StatusInternalServerError, ErrInternalServer, err.Error())
+}
+
+func ErrorHandler(ctx *gin.Context, err error) {
+	errors := asDetailedError(err)
+
+	logger.FromContext(ctx).Error(errors.Internal.Message,
+		slog.Int("status", errors.Internal.HTTPStatus),
+		slog.String("code", string(errors.Code)),
 	)
+	write(ctx, errors)
 }
 
 func Response(ctx *gin.Context, err error) {
-	errors, ok := err.(*DetailedError)
-	if !ok {
-		errors = newError(http.StatusInternalServerError, "Something went wrong", err.Error())
-	}
+	errors := asDetailedError(err)
 
 	if errors.External.HTTPStatus >= http.StatusUnauthorized {
-		log.Printf("[ERROR] status=%d | %s\n",
-			errors.Internal.HTTPStatus,
-			errors.Internal.Message,
+		logger.FromContext(ctx).Error(errors.Internal.Message,
+			slog.Int("status", errors.Internal.HTTPStatus),
+			slog.String("code", string(errors.Code)),
 		)
 	}
+	write(ctx, errors)
+}
+
+func write(ctx *gin.Context, errors *DetailedError) {
+	status := errors.External.HTTPStatus
+	requestID := logger.RequestID(ctx)
+	locale := i18n.FromContext(ctx)
+
+	message := errors.External.Message
+	if i18n.Has(locale, "error."+string(errors.Code)) {
+		message = LocalizedMessage(locale, errors.Code)
+	}
+	details := localizeDetails(locale, errors.Details)
+
+	if errors.RetryAfter > 0 {
+		seconds := int(math.Ceil(errors.RetryAfter.Seconds()))
+		ctx.Header("Retry-After", strconv.Itoa(seconds))
+	}
+
+	if problemJSON {
+		body := model.ProblemDetails{
+			Type:      "about:blank",
+			Title:     http.StatusText(status),
+			Status:    status,
+			Detail:    message,
+			Code:      string(errors.Code),
+			RequestID: requestID,
+			Errors:    details,
+		}
+		if ctx.Request != nil {
+			body.Instance = ctx.Request.URL.Path
+		}
+
+		ctx.Render(status, problemRender{body: body})
+		return
+	}
 
-	response.JSON(ctx, errors.External.HTTPStatus, errors.External.Message, nil)
+	ctx.JSON(status, model.ResponseError{
+		StatusCode: status,
+		Code:       string(errors.Code),
+		Message:    message,
+		RequestID:  requestID,
+		Details:    details,
+	})
 }
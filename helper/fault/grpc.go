@@ -0,0 +1,72 @@
+package fault
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/Reza1878/goesclearning/user-service/model"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// FromGRPC translates an error returned by a gRPC backend into the HTTP
+// error it stands for. Field violations carried in a google.rpc.BadRequest
+// detail become validation details.
+func FromGRPC(err error, internalMessage string) *DetailedError {
+	st := status.Convert(err)
+	httpStatus, code := httpStatusFromGRPC(st.Code())
+
+	detailed := newError(httpStatus, code, fmt.Sprintf("%s: %v", internalMessage, err))
+
+	for _, detail := range st.Details() {
+		if badRequest, ok := detail.(*errdetails.BadRequest); ok {
+			for _, violation := range badRequest.GetFieldViolations() {
+				detailed.Details = append(detailed.Details, fieldViolation(violation))
+			}
+		}
+	}
+
+	return detailed
+}
+
+func httpStatusFromGRPC(code codes.Code) (int, ErrorCode) {
+	switch code {
+	case codes.InvalidArgument, codes.OutOfRange:
+		return http.StatusBadRequest, ErrBadRequest
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized, ErrUnauthorized
+	case codes.PermissionDenied:
+		return http.StatusForbidden, ErrForbidden
+	case codes.NotFound:
+		return http.StatusNotFound, ErrNotFound
+	case codes.AlreadyExists, codes.Aborted:
+		return http.StatusConflict, ErrConflict
+	case codes.FailedPrecondition:
+		return http.StatusUnprocessableEntity, ErrUnprocessable
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests, ErrTooManyRequests
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout, ErrTimeout
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable, ErrUnavailable
+	case codes.Canceled:
+		return http.StatusRequestTimeout, ErrTimeout
+	default:
+		return http.StatusBadGateway, ErrInternalServer
+	}
+}
+
+func fieldViolation(violation *errdetails.BadRequest_FieldViolation) model.ErrorDetail {
+	rule := strings.ToLower(violation.GetReason())
+	if rule == "" {
+		rule = "invalid"
+	}
+
+	return model.ErrorDetail{
+		Field:   violation.GetField(),
+		Rule:    rule,
+		Message: violation.GetDescription(),
+	}
+}
@@ -0,0 +1,23 @@
+package fault
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/Reza1878/goesclearning/user-service/model"
+)
+
+const problemContentType = "application/problem+json"
+
+type problemRender struct {
+	body model.ProblemDetails
+}
+
+func (r problemRender) Render(w http.ResponseWriter) error {
+	r.WriteContentType(w)
+	return json.NewEncoder(w).Encode(r.body)
+}
+
+func (r problemRender) WriteContentType(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", problemContentType)
+}
@@ -0,0 +1,95 @@
+package fault
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/Reza1878/goesclearning/user-service/helper/i18n"
+	"github.com/Reza1878/goesclearning/user-service/model"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+func init() {
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterTagNameFunc(jsonFieldName)
+	}
+}
+
+func jsonFieldName(field reflect.StructField) string {
+	for _, tag := range []string{"json", "form"} {
+		name := strings.SplitN(field.Tag.Get(tag), ",", 2)[0]
+		if name == "-" {
+			return ""
+		}
+		if name != "" {
+			return name
+		}
+	}
+
+	return field.Name
+}
+
+// Validation turns a binding error into a 400 whose details list every
+// failed field, so clients don't have to parse the message.
+func Validation(err error) *DetailedError {
+	detailed := newError(
+		http.StatusBadRequest,
+		ErrBadRequest,
+		fmt.Sprintf("failed to bind request: %v", err),
+	)
+
+	var validationErrors validator.ValidationErrors
+	if errors.As(err, &validationErrors) {
+		for _, fe := range validationErrors {
+			detailed.Details = append(detailed.Details, FieldError(fe.Field(), fe.Tag(), fe.Param()))
+		}
+		return detailed
+	}
+
+	var typeError *json.UnmarshalTypeError
+	if errors.As(err, &typeError) {
+		detailed.Details = append(detailed.Details, FieldError(typeError.Field, "type", typeError.Type.String()))
+	}
+
+	return detailed
+}
+
+// FieldError builds a detail whose message comes from the validation
+// catalogue, so it can be localised when the response is written.
+func FieldError(field, rule, param string) model.ErrorDetail {
+	return model.ErrorDetail{
+		Field:   field,
+		Rule:    rule,
+		Param:   param,
+		Message: validationMessage(i18n.DefaultLocale, rule, param),
+	}
+}
+
+func validationMessage(locale, rule, param string) string {
+	key := "validation." + rule
+	if !i18n.Has(locale, key) {
+		key = "validation.default"
+	}
+
+	return i18n.Translate(locale, key, map[string]string{"param": param, "rule": rule})
+}
+
+func localizeDetails(locale string, details []model.ErrorDetail) []model.ErrorDetail {
+	if len(details) == 0 || locale == i18n.DefaultLocale {
+		return details
+	}
+
+	localized := make([]model.ErrorDetail, len(details))
+	for i, detail := range details {
+		localized[i] = detail
+		if i18n.Has(locale, "validation."+detail.Rule) {
+			localized[i].Message = validationMessage(locale, detail.Rule, detail.Param)
+		}
+	}
+	return localized
+}
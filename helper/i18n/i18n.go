@@ -0,0 +1,141 @@
+package i18n
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/text/language"
+)
+
+const (
+	English    = "en"
+	Indonesian = "id"
+
+	DefaultLocale = English
+	LocaleKey     = "locale"
+)
+
+type localeContextKey struct{}
+
+//go:embed locales/*.json
+var files embed.FS
+
+var (
+	catalogues = map[string]map[string]string{}
+	supported  []language.Tag
+	matcher    language.Matcher
+)
+
+func init() {
+	entries, err := files.ReadDir("locales")
+	if err != nil {
+		panic(fmt.Sprintf("i18n: failed to read locales: %v", err))
+	}
+
+	// the default locale goes first so the matcher falls back to it
+	supported = append(supported, language.Make(DefaultLocale))
+	for _, entry := range entries {
+		raw, err := files.ReadFile(path.Join("locales", entry.Name()))
+		if err != nil {
+			panic(fmt.Sprintf("i18n: failed to read %s: %v", entry.Name(), err))
+		}
+
+		messages := map[string]string{}
+		if err := json.Unmarshal(raw, &messages); err != nil {
+			panic(fmt.Sprintf("i18n: failed to parse %s: %v", entry.Name(), err))
+		}
+
+		locale := strings.TrimSuffix(entry.Name(), ".json")
+		catalogues[locale] = messages
+		if locale != DefaultLocale {
+			supported = append(supported, language.Make(locale))
+		}
+	}
+
+	matcher = language.NewMatcher(supported)
+}
+
+// Match picks the best supported locale for an Accept-Language value.
+func Match(acceptLanguage string) string {
+	tags, _, err := language.ParseAcceptLanguage(acceptLanguage)
+	if err != nil || len(tags) == 0 {
+		return DefaultLocale
+	}
+
+	_, index, confidence := matcher.Match(tags...)
+	if confidence == language.No {
+		return DefaultLocale
+	}
+
+	base, _ := supported[index].Base()
+	return base.String()
+}
+
+func Supported(locale string) bool {
+	_, ok := catalogues[locale]
+	return ok
+}
+
+func Has(locale, key string) bool {
+	if _, ok := catalogues[locale][key]; ok {
+		return true
+	}
+	_, ok := catalogues[DefaultLocale][key]
+	return ok
+}
+
+// Translate returns the message for key in locale, falling back to
+// English and finally to the key itself. Placeholders written as
+// {name} are replaced from args.
+func Translate(locale, key string, args map[string]string) string {
+	msg, ok := catalogues[locale][key]
+	if !ok {
+		msg, ok = catalogues[DefaultLocale][key]
+	}
+	if !ok {
+		return key
+	}
+
+	if len(args) == 0 {
+		return msg
+	}
+
+	pairs := make([]string, 0, len(args)*2)
+	for name, value := range args {
+		pairs = append(pairs, "{"+name+"}", value)
+	}
+	return strings.NewReplacer(pairs...).Replace(msg)
+}
+
+func WithLocale(ctx context.Context, locale string) context.Context {
+	return context.WithValue(ctx, localeContextKey{}, locale)
+}
+
+func FromContext(ctx context.Context) string {
+	if ctx == nil {
+		return DefaultLocale
+	}
+
+	if c, ok := ctx.(*gin.Context); ok {
+		if locale := c.GetString(LocaleKey); locale != "" {
+			return locale
+		}
+		if c.Request != nil {
+			ctx = c.Request.Context()
+		}
+	}
+
+	if locale, ok := ctx.Value(localeContextKey{}).(string); ok {
+		return locale
+	}
+	return DefaultLocale
+}
+
+func T(ctx context.Context, key string, args map[string]string) string {
+	return Translate(FromContext(ctx), key, args)
+}
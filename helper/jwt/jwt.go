@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/Reza1878/goesclearning/user-service/helper/fault"
+	"github.com/Reza1878/goesclearning/user-service/model"
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v4"
 	"github.com/google/uuid"
@@ -14,58 +15,99 @@ import (
 const tokenExpiry = 30 * time.Minute
 const refreshTokenExpiry = 72 * time.Hour
 
-var signedKey = []byte("secret")
+var signedKey []byte
+
+func SetSigningKey(key []byte) {
+	signedKey = key
+}
+
+const ClaimsKey = "jwt_claims"
+
+// Token types, carried in the typ claim. Only access tokens authenticate
+// requests; refresh tokens are only accepted by the refresh endpoint.
+const (
+	TypeAccess  = "access"
+	TypeRefresh = "refresh"
+)
 
 type JWTPayload struct {
 	Name   string
 	Email  string
 	UserId string
+	Role   string
+	// SessionId is the login session the token belongs to; revoking the
+	// session invalidates the token.
+	SessionId string
+	// FamilyId is shared by the refresh tokens of one session.
+	FamilyId string
+	Type     string `json:"typ,omitempty"`
 	jwt.RegisteredClaims
 }
 
-func CreateAccessToken(name, email, userId string) (*string, *JWTPayload, error) {
-	return generateToken(name, email, userId, tokenExpiry)
+// Subject is the user and session a token is issued for.
+type Subject struct {
+	Name      string
+	Email     string
+	UserId    string
+	Role      string
+	SessionId string
+	FamilyId  string
 }
 
-func CreateRefreshToken(name, email, userId string) (*string, *JWTPayload, error) {
-	return generateToken(name, email, userId, refreshTokenExpiry)
+// RefreshTokenExpiry is how long a refresh token, and so a session, lasts.
+func RefreshTokenExpiry() time.Duration {
+	return refreshTokenExpiry
 }
 
-func generateToken(name, email, userId string, duration time.Duration) (*string, *JWTPayload, error) {
-	payload, err := newJWTPayload(name, email, userId, duration)
-	if err != nil {
-		return nil, nil, err
-	}
+func (p *JWTPayload) IsAdmin() bool {
+	return p.Role == model.RoleAdmin
+}
 
-	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, payload).SignedString(signedKey)
+func CreateAccessToken(subject Subject) (*string, *JWTPayload, error) {
+	tokenID, err := uuid.NewRandom()
 	if err != nil {
 		return nil, nil, fault.Custom(
 			http.StatusUnprocessableEntity,
 			fault.ErrUnprocessable,
-			"failed signing JWT token: "+err.Error(),
+			"failed to generate token ID: "+err.Error(),
 		)
 	}
 
-	return &token, payload, nil
+	return generateToken(newJWTPayload(subject, TypeAccess, tokenID, tokenExpiry))
 }
 
-func newJWTPayload(name, email, userId string, duration time.Duration) (*JWTPayload, error) {
-	tokenID, err := uuid.NewRandom()
+// CreateRefreshToken issues a refresh token with the given ID, which the
+// session stores so that only its latest refresh token is accepted.
+func CreateRefreshToken(subject Subject, tokenID uuid.UUID) (*string, *JWTPayload, error) {
+	return generateToken(newJWTPayload(subject, TypeRefresh, tokenID, refreshTokenExpiry))
+}
+
+func generateToken(payload *JWTPayload) (*string, *JWTPayload, error) {
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, payload).SignedString(signedKey)
 	if err != nil {
-		return nil, fault.Custom(
+		return nil, nil, fault.Custom(
 			http.StatusUnprocessableEntity,
 			fault.ErrUnprocessable,
-			"failed to generate token ID: "+err.Error(),
+			"failed signing JWT token: "+err.Error(),
 		)
 	}
 
+	return &token, payload, nil
+}
+
+func newJWTPayload(subject Subject, typ string, tokenID uuid.UUID, duration time.Duration) *JWTPayload {
 	now := time.Now()
 	exp := now.Add(duration)
 
 	return &JWTPayload{
-		Name:   name,
-		Email:  email,
-		UserId: userId,
+		Name:      subject.Name,
+		Email:     subject.Email,
+		UserId:    subject.UserId,
+		Role:      subject.Role,
+		SessionId: subject.SessionId,
+		FamilyId:  subject.FamilyId,
+		Type:      typ,
 		RegisteredClaims: jwt.RegisteredClaims{
 			Issuer:    "user_login",
 			Subject:   "go-escape",
@@ -74,7 +116,7 @@ func newJWTPayload(name, email, userId string, duration time.Duration) (*JWTPayl
 			NotBefore: jwt.NewNumericDate(now),
 			ExpiresAt: jwt.NewNumericDate(exp),
 		},
-	}, nil
+	}
 }
 
 func GetTokenFromHeader(ctx *gin.Context) (string, error) {
@@ -88,7 +130,7 @@ func GetTokenFromHeader(ctx *gin.Context) (string, error) {
 	}
 
 	splittedToken := strings.Split(token, " ")
-	if len(splittedToken) == 0 {
+	if len(splittedToken) != 2 || splittedToken[1] == "" {
 		return "", fault.Custom(
 			http.StatusUnauthorized,
 			fault.ErrUnauthorized,
@@ -121,3 +163,17 @@ func GetClaims(token string) (*JWTPayload, error) {
 		"invalid token",
 	)
 }
+
+func ClaimsFromContext(ctx *gin.Context) (*JWTPayload, error) {
+	if claims, ok := ctx.Get(ClaimsKey); ok {
+		if payload, ok := claims.(*JWTPayload); ok {
+			return payload, nil
+		}
+	}
+
+	return nil, fault.Custom(
+		http.StatusUnauthorized,
+		fault.ErrUnauthorized,
+		"claims not found in request context",
+	)
+}
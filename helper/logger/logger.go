@@ -0,0 +1,95 @@
+package logger
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	RequestIDKey = "request_id"
+	UserIDKey    = "user_id"
+)
+
+type requestIDContextKey struct{}
+
+type Config struct {
+	Level  string
+	Format string
+}
+
+// Init builds the process wide logger from cfg and installs it as the
+// slog default, so packages that only have a context can still log.
+func Init(cfg Config) *slog.Logger {
+	l := New(os.Stdout, cfg)
+	slog.SetDefault(l)
+	return l
+}
+
+func New(w io.Writer, cfg Config) *slog.Logger {
+	opts := &slog.HandlerOptions{Level: ParseLevel(cfg.Level)}
+
+	var handler slog.Handler
+	if strings.EqualFold(cfg.Format, "text") {
+		handler = slog.NewTextHandler(w, opts)
+	} else {
+		handler = slog.NewJSONHandler(w, opts)
+	}
+
+	return slog.New(handler)
+}
+
+func ParseLevel(level string) slog.Level {
+	switch strings.ToLower(level) {
+	case "debug":
+		return slog.LevelDebug
+	case "warn", "warning":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDContextKey{}, requestID)
+}
+
+// RequestID returns the request ID carried by ctx. A *gin.Context is
+// looked up through its keys first, everything else through its values.
+func RequestID(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+
+	if c, ok := ctx.(*gin.Context); ok {
+		if id := c.GetString(RequestIDKey); id != "" {
+			return id
+		}
+		if c.Request != nil {
+			ctx = c.Request.Context()
+		}
+	}
+
+	id, _ := ctx.Value(requestIDContextKey{}).(string)
+	return id
+}
+
+// FromContext returns the default logger annotated with the request ID
+// of ctx, if any.
+func FromContext(ctx context.Context) *slog.Logger {
+	l := slog.Default()
+	if id := RequestID(ctx); id != "" {
+		l = l.With(slog.String(RequestIDKey, id))
+	}
+	return l
+}
+
+func SetUserID(ctx *gin.Context, userID string) {
+	ctx.Set(UserIDKey, userID)
+}
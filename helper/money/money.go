@@ -0,0 +1,147 @@
+package money
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// DefaultCurrency is used for amounts that arrive without a currency,
+// i.e. the deprecated float prices.
+const DefaultCurrency = "IDR"
+
+var (
+	ErrInvalidAmount = errors.New("invalid decimal amount")
+	ErrPrecision     = errors.New("amount has more decimal places than the currency allows")
+	ErrOverflow      = errors.New("amount is out of range")
+)
+
+// Money is an exact amount in the minor unit of an ISO 4217 currency,
+// e.g. {1999, "USD"} is 19.99 USD.
+type Money struct {
+	Minor    int64
+	Currency string
+}
+
+// exponents lists the currencies whose minor unit is not 1/100.
+var exponents = map[string]int{
+	"BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "ISK": 0, "JPY": 0, "KMF": 0,
+	"KRW": 0, "PYG": 0, "RWF": 0, "UGX": 0, "UYI": 0, "VND": 0, "VUV": 0,
+	"XAF": 0, "XOF": 0, "XPF": 0,
+
+	"BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+}
+
+// Exponent is the number of decimal places of the currency's minor unit.
+func Exponent(currency string) int {
+	if exp, ok := exponents[currency]; ok {
+		return exp
+	}
+	return 2
+}
+
+// Parse reads a decimal string such as "19.99". It rejects exponents and
+// more decimal places than the currency has.
+func Parse(amount, currency string) (Money, error) {
+	minor, err := parseMinor(amount, Exponent(currency), false)
+	if err != nil {
+		return Money{}, err
+	}
+	return Money{Minor: minor, Currency: currency}, nil
+}
+
+// FromFloat converts a deprecated float price. The float is formatted
+// with the fewest digits that round-trip, so 19.99 stays 19.99, and any
+// extra decimal places are rounded half away from zero.
+func FromFloat(f float64, currency string) (Money, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return Money{}, ErrInvalidAmount
+	}
+
+	minor, err := parseMinor(strconv.FormatFloat(f, 'f', -1, 64), Exponent(currency), true)
+	if err != nil {
+		return Money{}, err
+	}
+	return Money{Minor: minor, Currency: currency}, nil
+}
+
+func parseMinor(s string, exp int, round bool) (int64, error) {
+	negative := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(s, "-")
+
+	whole, frac, _ := strings.Cut(s, ".")
+	if whole == "" || !digits(whole) || !digits(frac) {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidAmount, s)
+	}
+
+	carry := false
+	if len(frac) > exp {
+		extra := frac[exp:]
+		frac = frac[:exp]
+		if !round && strings.Trim(extra, "0") != "" {
+			return 0, ErrPrecision
+		}
+		carry = round && extra[0] >= '5'
+	}
+	frac += strings.Repeat("0", exp-len(frac))
+
+	minor, err := strconv.ParseInt(whole+frac, 10, 64)
+	if err != nil {
+		return 0, ErrOverflow
+	}
+	if carry {
+		if minor == math.MaxInt64 {
+			return 0, ErrOverflow
+		}
+		minor++
+	}
+
+	if negative {
+		minor = -minor
+	}
+	return minor, nil
+}
+
+func digits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// String formats the amount as a decimal string without the currency.
+func (m Money) String() string {
+	exp := Exponent(m.Currency)
+
+	minor := m.Minor
+	sign := ""
+	if minor < 0 {
+		sign = "-"
+	}
+
+	s := strconv.FormatUint(absMinor(minor), 10)
+	if exp == 0 {
+		return sign + s
+	}
+	if len(s) <= exp {
+		s = strings.Repeat("0", exp-len(s)+1) + s
+	}
+	return sign + s[:len(s)-exp] + "." + s[len(s)-exp:]
+}
+
+// Float approximates the amount for the deprecated float fields. Never
+// do arithmetic on the result.
+func (m Money) Float() float64 {
+	return float64(m.Minor) / math.Pow10(Exponent(m.Currency))
+}
+
+func absMinor(minor int64) uint64 {
+	if minor < 0 {
+		return uint64(-(minor + 1)) + 1
+	}
+	return uint64(minor)
+}
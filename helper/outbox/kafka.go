@@ -0,0 +1,82 @@
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+type kafkaRESTPublisher struct {
+	baseURL string
+	client  *http.Client
+}
+
+// NewKafkaRESTPublisher produces each event to the Kafka topic named by
+// its topic through a Kafka REST Proxy (v2 API). The event key is the
+// record key and the whole event, dedupe ID included, is the value.
+func NewKafkaRESTPublisher(baseURL string, client *http.Client) (Publisher, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid Kafka REST proxy URL %q", baseURL)
+	}
+
+	return &kafkaRESTPublisher{baseURL: strings.TrimRight(baseURL, "/"), client: client}, nil
+}
+
+type kafkaRecord struct {
+	Key   string `json:"key"`
+	Value Event  `json:"value"`
+}
+
+type kafkaProduceResponse struct {
+	Offsets []struct {
+		Partition int     `json:"partition"`
+		Offset    int64   `json:"offset"`
+		ErrorCode *int    `json:"error_code"`
+		Error     *string `json:"error"`
+	} `json:"offsets"`
+}
+
+func (p *kafkaRESTPublisher) Publish(ctx context.Context, e Event) error {
+	body, err := json.Marshal(map[string][]kafkaRecord{
+		"records": {{Key: e.Key, Value: e}},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode event %s: %w", e.Id, err)
+	}
+
+	endpoint := p.baseURL + "/topics/" + url.PathEscape(e.Topic)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/vnd.kafka.json.v2+json")
+	req.Header.Set("Accept", "application/vnd.kafka.v2+json")
+
+	res, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to produce %s to Kafka: %w", e.Id, err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		detail, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+		return fmt.Errorf("Kafka REST proxy rejected %s with %d: %s", e.Id, res.StatusCode, bytes.TrimSpace(detail))
+	}
+
+	var produced kafkaProduceResponse
+	if err := json.NewDecoder(res.Body).Decode(&produced); err != nil {
+		return fmt.Errorf("invalid Kafka REST proxy response for %s: %w", e.Id, err)
+	}
+	for _, offset := range produced.Offsets {
+		if offset.Error != nil {
+			return fmt.Errorf("Kafka rejected %s: %s", e.Id, *offset.Error)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestKafkaRESTPublisher(t *testing.T) {
+	e, err := NewEvent(TopicUserRegistered, "user-1", map[string]string{"email": "alice@example.com"})
+	if err != nil {
+		t.Fatalf("NewEvent: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		wantErr string
+	}{
+		{"produced", http.StatusOK, `{"offsets":[{"partition":0,"offset":42}]}`, ""},
+		{"record error", http.StatusOK, `{"offsets":[{"partition":0,"offset":-1,"error_code":50002,"error":"not enough replicas"}]}`, "not enough replicas"},
+		{"proxy error", http.StatusNotFound, `{"error_code":40401,"message":"Topic not found."}`, "rejected " + e.Id.String() + " with 404"},
+		{"invalid response", http.StatusOK, `not json`, "invalid Kafka REST proxy response"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var records map[string][]kafkaRecord
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodPost || r.URL.Path != "/topics/"+TopicUserRegistered {
+					t.Errorf("request %s %s, want POST /topics/%s", r.Method, r.URL.Path, TopicUserRegistered)
+				}
+				if ct := r.Header.Get("Content-Type"); ct != "application/vnd.kafka.json.v2+json" {
+					t.Errorf("Content-Type = %q", ct)
+				}
+				body, _ := io.ReadAll(r.Body)
+				if err := json.Unmarshal(body, &records); err != nil {
+					t.Errorf("request body %s: %v", body, err)
+				}
+
+				w.WriteHeader(tt.status)
+				io.WriteString(w, tt.body)
+			}))
+			defer srv.Close()
+
+			p, err := NewKafkaRESTPublisher(srv.URL+"/", srv.Client())
+			if err != nil {
+				t.Fatalf("NewKafkaRESTPublisher: %v", err)
+			}
+
+			err = p.Publish(context.Background(), e)
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("Publish: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("Publish: got %v, want an error containing %q", err, tt.wantErr)
+			}
+
+			if got := records["records"]; len(got) != 1 || got[0].Key != e.Key || got[0].Value.Id != e.Id {
+				t.Fatalf("records = %+v, want event %s keyed %q", got, e.Id, e.Key)
+			}
+		})
+	}
+}
+
+func TestKafkaRESTPublisherUnreachable(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	url := srv.URL
+	srv.Close()
+
+	p, err := NewKafkaRESTPublisher(url, http.DefaultClient)
+	if err != nil {
+		t.Fatalf("NewKafkaRESTPublisher: %v", err)
+	}
+
+	e, _ := NewEvent(TopicUserRegistered, "user-1", nil)
+	if err := p.Publish(context.Background(), e); err == nil || !strings.Contains(err.Error(), "failed to produce") {
+		t.Fatalf("Publish: got %v, want a produce error", err)
+	}
+}
+
+func TestNewKafkaRESTPublisherRejectsURL(t *testing.T) {
+	for _, raw := range []string{"localhost:8082", "ftp://proxy", "http://"} {
+		if _, err := NewKafkaRESTPublisher(raw, http.DefaultClient); err == nil {
+			t.Errorf("NewKafkaRESTPublisher(%q) accepted the URL", raw)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package outbox
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type memoryEntry struct {
+	event       Event
+	nextAttempt time.Time
+	lastError   string
+	publishedAt *time.Time
+}
+
+// MemoryStore is an in-process outbox for development and tests. Add
+// stands in for Insert, since there is no transaction to join.
+type MemoryStore struct {
+	mu      sync.Mutex
+	entries map[uuid.UUID]*memoryEntry
+}
+
+func NewMemory() *MemoryStore {
+	return &MemoryStore{entries: map[uuid.UUID]*memoryEntry{}}
+}
+
+func (s *MemoryStore) Add(e Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.entries[e.Id] = &memoryEntry{event: e, nextAttempt: e.CreatedAt}
+}
+
+func (s *MemoryStore) Claim(_ context.Context, limit int, leaseUntil time.Time) ([]Event, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	var due []*memoryEntry
+	for _, entry := range s.entries {
+		if entry.publishedAt == nil && !entry.nextAttempt.After(now) {
+			due = append(due, entry)
+		}
+	}
+
+	sort.Slice(due, func(i, j int) bool {
+		return due[i].event.CreatedAt.Before(due[j].event.CreatedAt)
+	})
+	if len(due) > limit {
+		due = due[:limit]
+	}
+
+	events := make([]Event, 0, len(due))
+	for _, entry := range due {
+		entry.nextAttempt = leaseUntil
+		entry.event.Attempts++
+		events = append(events, entry.event)
+	}
+	return events, nil
+}
+
+func (s *MemoryStore) MarkPublished(_ context.Context, id uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if entry, ok := s.entries[id]; ok {
+		now := time.Now()
+		entry.publishedAt = &now
+		entry.lastError = ""
+	}
+	return nil
+}
+
+func (s *MemoryStore) MarkFailed(_ context.Context, id uuid.UUID, cause error, retryAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if entry, ok := s.entries[id]; ok {
+		entry.lastError = cause.Error()
+		entry.nextAttempt = retryAt
+	}
+	return nil
+}
+
+func (s *MemoryStore) Prune(_ context.Context, before time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var n int64
+	for id, entry := range s.entries {
+		if entry.publishedAt != nil && entry.publishedAt.Before(before) {
+			delete(s.entries, id)
+			n++
+		}
+	}
+	return n, nil
+}
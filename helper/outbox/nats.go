@@ -0,0 +1,312 @@
+package outbox
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// natsMsgIdHeader makes JetStream drop duplicates within its dedupe
+// window.
+const natsMsgIdHeader = "Nats-Msg-Id"
+
+// natsReplySid is the subscription ID of the acknowledgement inbox.
+const natsReplySid = "1"
+
+type natsPublisher struct {
+	addr    string
+	user    *url.Userinfo
+	timeout time.Duration
+	mu      sync.Mutex
+	conn    net.Conn
+	reader  *bufio.Reader
+	// inbox is the prefix of the reply subjects JetStream acknowledges on
+	inbox   string
+	replies uint64
+}
+
+// NewNATSPublisher publishes each event as JSON to the subject named by
+// its topic, speaking the NATS client protocol directly. rawURL is
+// nats://[user:password@]host[:port]; TLS is not supported. Publish
+// waits for the JetStream acknowledgement, so every topic must be
+// captured by a stream; a publish no stream stores fails.
+func NewNATSPublisher(rawURL string, timeout time.Duration) (Publisher, error) {
+	u, err := url.Parse(rawURL)
+	if err == nil && u.Scheme == "tls" {
+		return nil, fmt.Errorf("invalid NATS URL %q, TLS is not supported", rawURL)
+	}
+	if err != nil || (u.Scheme != "nats" && u.Scheme != "tcp") || u.Hostname() == "" {
+		return nil, fmt.Errorf("invalid NATS URL %q, expected nats://host:port", rawURL)
+	}
+
+	addr := u.Host
+	if u.Port() == "" {
+		addr = net.JoinHostPort(u.Hostname(), "4222")
+	}
+
+	return &natsPublisher{addr: addr, user: u.User, timeout: timeout}, nil
+}
+
+func (p *natsPublisher) Publish(ctx context.Context, e Event) error {
+	body, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("failed to encode event %s: %w", e.Id, err)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err := p.publish(ctx, e, body); err != nil {
+		// start over with a fresh connection next time
+		p.close()
+		return err
+	}
+	return nil
+}
+
+func (p *natsPublisher) publish(ctx context.Context, e Event, body []byte) error {
+	if p.conn == nil {
+		if err := p.connect(ctx); err != nil {
+			return err
+		}
+	}
+
+	if err := p.conn.SetDeadline(p.deadline(ctx)); err != nil {
+		return err
+	}
+
+	p.replies++
+	reply := fmt.Sprintf("%s.%d", p.inbox, p.replies)
+	header := fmt.Sprintf("NATS/1.0\r\n%s: %s\r\n\r\n", natsMsgIdHeader, e.Id)
+	frame := fmt.Sprintf("HPUB %s %s %d %d\r\n%s%s\r\n", e.Topic, reply, len(header), len(header)+len(body), header, body)
+
+	if _, err := p.conn.Write([]byte(frame)); err != nil {
+		return fmt.Errorf("failed to publish %s to NATS: %w", e.Id, err)
+	}
+	return p.awaitAck(e, reply)
+}
+
+func (p *natsPublisher) connect(ctx context.Context) error {
+	dialer := net.Dialer{Timeout: p.timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", p.addr)
+	if err != nil {
+		return fmt.Errorf("failed to connect to NATS at %s: %w", p.addr, err)
+	}
+	p.conn = conn
+	p.reader = bufio.NewReader(conn)
+
+	if err := conn.SetDeadline(p.deadline(ctx)); err != nil {
+		return err
+	}
+
+	line, err := p.readLine()
+	if err != nil {
+		return fmt.Errorf("failed to read NATS INFO: %w", err)
+	}
+	if !strings.HasPrefix(line, "INFO ") {
+		return fmt.Errorf("unexpected NATS greeting %q", line)
+	}
+
+	var info struct {
+		Headers     bool `json:"headers"`
+		TLSRequired bool `json:"tls_required"`
+	}
+	if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "INFO ")), &info); err != nil {
+		return fmt.Errorf("invalid NATS INFO: %w", err)
+	}
+	if info.TLSRequired {
+		return errors.New("NATS server requires TLS, which this publisher does not support")
+	}
+	if !info.Headers {
+		return errors.New("NATS server does not support headers, which JetStream publishing needs")
+	}
+
+	connect := map[string]interface{}{
+		"verbose":  false,
+		"pedantic": false,
+		"headers":  true,
+		// a publish no stream captures is answered with a 503 status
+		// instead of going unanswered until the deadline
+		"no_responders": true,
+		"name":          "user-service",
+		"lang":          "go",
+		"version":       "1.0.0",
+		"protocol":      1,
+	}
+	if p.user != nil {
+		connect["user"] = p.user.Username()
+		if password, ok := p.user.Password(); ok {
+			connect["pass"] = password
+		}
+	}
+	options, _ := json.Marshal(connect)
+
+	p.inbox = "_INBOX." + uuid.NewString()
+	p.replies = 0
+	if _, err := fmt.Fprintf(conn, "CONNECT %s\r\nSUB %s.* %s\r\nPING\r\n", options, p.inbox, natsReplySid); err != nil {
+		return fmt.Errorf("failed to send NATS CONNECT: %w", err)
+	}
+	return p.awaitPong()
+}
+
+// awaitPong reads until the server answers our PING, which it does only
+// after processing everything sent before it.
+func (p *natsPublisher) awaitPong() error {
+	for {
+		line, err := p.readLine()
+		if err != nil {
+			return fmt.Errorf("failed to read NATS reply: %w", err)
+		}
+
+		switch {
+		case line == "PONG":
+			return nil
+		case line == "PING":
+			if _, err := p.conn.Write([]byte("PONG\r\n")); err != nil {
+				return err
+			}
+		case strings.HasPrefix(line, "-ERR"):
+			return fmt.Errorf("NATS error: %s", strings.TrimSpace(strings.TrimPrefix(line, "-ERR")))
+		}
+	}
+}
+
+// awaitAck reads until the reply to the publish of e arrives on reply and
+// checks that JetStream stored the event.
+func (p *natsPublisher) awaitAck(e Event, reply string) error {
+	for {
+		line, err := p.readLine()
+		if err != nil {
+			return fmt.Errorf("no JetStream acknowledgement for %s: %w", e.Id, err)
+		}
+
+		switch {
+		case line == "PING":
+			if _, err := p.conn.Write([]byte("PONG\r\n")); err != nil {
+				return err
+			}
+		case strings.HasPrefix(line, "-ERR"):
+			return fmt.Errorf("NATS error: %s", strings.TrimSpace(strings.TrimPrefix(line, "-ERR")))
+		case strings.HasPrefix(line, "MSG ") || strings.HasPrefix(line, "HMSG "):
+			msg, err := p.readMsg(line)
+			if err != nil {
+				return err
+			}
+			if msg.subject != reply {
+				continue
+			}
+			return msg.ack(e)
+		}
+	}
+}
+
+type natsMsg struct {
+	subject string
+	header  string
+	payload []byte
+}
+
+// readMsg reads the body of a MSG or HMSG whose control line is line.
+func (p *natsPublisher) readMsg(line string) (*natsMsg, error) {
+	fields := strings.Fields(line)
+
+	// MSG <subject> <sid> [reply] <size>
+	// HMSG <subject> <sid> [reply] <header size> <total size>
+	sizes := 1
+	if fields[0] == "HMSG" {
+		sizes = 2
+	}
+	if len(fields) != 3+sizes && len(fields) != 4+sizes {
+		return nil, fmt.Errorf("malformed NATS message %q", line)
+	}
+
+	var headerSize, total int
+	var err error
+	if total, err = strconv.Atoi(fields[len(fields)-1]); err == nil && sizes == 2 {
+		headerSize, err = strconv.Atoi(fields[len(fields)-2])
+	}
+	if err != nil || headerSize < 0 || headerSize > total {
+		return nil, fmt.Errorf("malformed NATS message %q", line)
+	}
+
+	data := make([]byte, total+2)
+	if _, err := io.ReadFull(p.reader, data); err != nil {
+		return nil, fmt.Errorf("failed to read NATS message: %w", err)
+	}
+
+	return &natsMsg{
+		subject: fields[1],
+		header:  string(data[:headerSize]),
+		payload: data[headerSize:total],
+	}, nil
+}
+
+// ack checks a JetStream PubAck, {"stream":"...","seq":1}, or the error
+// or status sent in its place.
+func (m *natsMsg) ack(e Event) error {
+	if m.header != "" {
+		// the status follows the version on the first header line, as in
+		// "NATS/1.0 503"
+		status, _, _ := strings.Cut(m.header, "\r\n")
+		if code := strings.TrimSpace(strings.TrimPrefix(status, "NATS/1.0")); code != "" {
+			if strings.HasPrefix(code, "503") {
+				return fmt.Errorf("no JetStream stream captures %s, event %s was not stored", e.Topic, e.Id)
+			}
+			return fmt.Errorf("NATS answered the publish of %s with status %s", e.Id, code)
+		}
+	}
+
+	var ack struct {
+		Stream string `json:"stream"`
+		Seq    uint64 `json:"seq"`
+		Error  *struct {
+			Code        int    `json:"code"`
+			ErrCode     int    `json:"err_code"`
+			Description string `json:"description"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(m.payload, &ack); err != nil {
+		return fmt.Errorf("invalid JetStream acknowledgement for %s: %w", e.Id, err)
+	}
+	if ack.Error != nil {
+		return fmt.Errorf("JetStream rejected %s: %s (%d)", e.Id, ack.Error.Description, ack.Error.ErrCode)
+	}
+	if ack.Stream == "" {
+		return fmt.Errorf("invalid JetStream acknowledgement for %s: %s", e.Id, m.payload)
+	}
+	return nil
+}
+
+func (p *natsPublisher) readLine() (string, error) {
+	line, err := p.reader.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+func (p *natsPublisher) deadline(ctx context.Context) time.Time {
+	deadline := time.Now().Add(p.timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		return d
+	}
+	return deadline
+}
+
+func (p *natsPublisher) close() {
+	if p.conn != nil {
+		p.conn.Close()
+		p.conn = nil
+	}
+}
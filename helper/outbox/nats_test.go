@@ -0,0 +1,217 @@
+package outbox
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// natsReply answers one HPUB; it returns the frame to send back to the
+// reply subject on sid, or "" to stay silent.
+type natsReply func(reply, sid string) string
+
+func ackReply(payload string) natsReply {
+	return func(reply, sid string) string {
+		return fmt.Sprintf("MSG %s %s %d\r\n%s\r\n", reply, sid, len(payload), payload)
+	}
+}
+
+func noResponders(reply, sid string) string {
+	header := "NATS/1.0 503\r\n\r\n"
+	return fmt.Sprintf("HMSG %s %s %d %d\r\n%s\r\n", reply, sid, len(header), len(header), header)
+}
+
+type natsPublish struct {
+	subject string
+	header  string
+	body    []byte
+}
+
+// natsStub stands in for a NATS server on a local listener. It accepts
+// one connection at a time and records what the client sends.
+type natsStub struct {
+	info      string
+	reply     natsReply
+	connect   chan map[string]any
+	published chan natsPublish
+}
+
+func newNATSStub(t *testing.T, info string, reply natsReply) (*natsStub, string) {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	stub := &natsStub{
+		info:      info,
+		reply:     reply,
+		connect:   make(chan map[string]any, 10),
+		published: make(chan natsPublish, 10),
+	}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go stub.serve(conn)
+		}
+	}()
+
+	return stub, "nats://" + ln.Addr().String()
+}
+
+func (s *natsStub) serve(conn net.Conn) {
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	fmt.Fprintf(conn, "INFO %s\r\n", s.info)
+
+	var sid string
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		switch fields[0] {
+		case "CONNECT":
+			var options map[string]any
+			json.Unmarshal([]byte(strings.TrimPrefix(strings.TrimSpace(line), "CONNECT ")), &options)
+			s.connect <- options
+		case "SUB":
+			sid = fields[len(fields)-1]
+		case "PING":
+			fmt.Fprint(conn, "PONG\r\n")
+		case "HPUB":
+			// HPUB <subject> <reply> <header size> <total size>
+			headerSize, _ := strconv.Atoi(fields[3])
+			total, _ := strconv.Atoi(fields[4])
+			data := make([]byte, total+2)
+			if _, err := io.ReadFull(r, data); err != nil {
+				return
+			}
+			s.published <- natsPublish{subject: fields[1], header: string(data[:headerSize]), body: data[headerSize:total]}
+
+			if frame := s.reply(fields[2], sid); frame != "" {
+				fmt.Fprint(conn, frame)
+			}
+		}
+	}
+}
+
+func TestNATSPublisher(t *testing.T) {
+	e, err := NewEvent(TopicUserRegistered, "user-1", map[string]string{"email": "alice@example.com"})
+	if err != nil {
+		t.Fatalf("NewEvent: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		info    string
+		reply   natsReply
+		wantErr string
+	}{
+		{"acknowledged", `{"headers":true}`, ackReply(`{"stream":"USERS","seq":7}`), ""},
+		{"duplicate", `{"headers":true}`, ackReply(`{"stream":"USERS","seq":7,"duplicate":true}`), ""},
+		{"stream error", `{"headers":true}`, ackReply(`{"error":{"code":503,"err_code":10077,"description":"maximum messages exceeded"}}`), "maximum messages exceeded"},
+		{"no responders", `{"headers":true}`, noResponders, "no JetStream stream captures user.registered"},
+		{"not an ack", `{"headers":true}`, ackReply(`{}`), "invalid JetStream acknowledgement"},
+		{"no answer", `{"headers":true}`, func(string, string) string { return "" }, "no JetStream acknowledgement"},
+		{"server error", `{"headers":true}`, func(string, string) string { return "-ERR 'Permissions Violation'\r\n" }, "Permissions Violation"},
+		{"no headers", `{"headers":false}`, ackReply(`{"stream":"USERS","seq":7}`), "does not support headers"},
+		{"tls", `{"headers":true,"tls_required":true}`, ackReply(`{"stream":"USERS","seq":7}`), "requires TLS"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stub, addr := newNATSStub(t, tt.info, tt.reply)
+
+			p, err := NewNATSPublisher(addr, 200*time.Millisecond)
+			if err != nil {
+				t.Fatalf("NewNATSPublisher: %v", err)
+			}
+
+			err = p.Publish(context.Background(), e)
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("Publish: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("Publish: got %v, want an error containing %q", err, tt.wantErr)
+			}
+			if strings.Contains(tt.info, `"headers":false`) || strings.Contains(tt.info, "tls_required") {
+				return
+			}
+
+			options := <-stub.connect
+			if options["headers"] != true || options["no_responders"] != true {
+				t.Fatalf("CONNECT options = %v, want headers and no_responders", options)
+			}
+
+			msg := <-stub.published
+			if msg.subject != TopicUserRegistered {
+				t.Fatalf("published to %q, want %q", msg.subject, TopicUserRegistered)
+			}
+			if !strings.Contains(msg.header, natsMsgIdHeader+": "+e.Id.String()) {
+				t.Fatalf("header %q lacks the dedupe ID", msg.header)
+			}
+			var got Event
+			if err := json.Unmarshal(msg.body, &got); err != nil || got.Id != e.Id {
+				t.Fatalf("published body %s, want event %s", msg.body, e.Id)
+			}
+		})
+	}
+}
+
+func TestNATSPublisherReconnectsAfterFailure(t *testing.T) {
+	acks := make(chan string, 2)
+	acks <- ""
+	acks <- `{"stream":"USERS","seq":1}`
+
+	stub, addr := newNATSStub(t, `{"headers":true}`, func(reply, sid string) string {
+		payload := <-acks
+		if payload == "" {
+			return ""
+		}
+		return ackReply(payload)(reply, sid)
+	})
+
+	p, err := NewNATSPublisher(addr, 200*time.Millisecond)
+	if err != nil {
+		t.Fatalf("NewNATSPublisher: %v", err)
+	}
+
+	e, _ := NewEvent(TopicUserRegistered, "user-1", nil)
+	if err := p.Publish(context.Background(), e); err == nil {
+		t.Fatalf("first Publish succeeded without an acknowledgement")
+	}
+	if err := p.Publish(context.Background(), e); err != nil {
+		t.Fatalf("second Publish: %v", err)
+	}
+
+	if n := len(stub.connect); n != 2 {
+		t.Fatalf("connected %d times, want a fresh connection after the failure", n)
+	}
+}
+
+func TestNewNATSPublisherRejectsURL(t *testing.T) {
+	for _, raw := range []string{"http://localhost:4222", "tls://localhost:4222", "nats://", "::"} {
+		if _, err := NewNATSPublisher(raw, time.Second); err == nil {
+			t.Errorf("NewNATSPublisher(%q) accepted the URL", raw)
+		}
+	}
+}
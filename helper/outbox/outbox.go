@@ -0,0 +1,66 @@
+// Package outbox implements the transactional outbox: domain events are
+// stored in the same transaction as the state change they describe and
+// published afterwards by a Relay. Delivery is at least once; consumers
+// drop duplicates by the event ID.
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// User lifecycle topics. Only user.registered has a flow emitting it so
+// far; the others are reserved for the flows that will.
+const (
+	TopicUserRegistered      = "user.registered"
+	TopicUserEmailChanged    = "user.email_changed"
+	TopicUserDeleted         = "user.deleted"
+	TopicUserPasswordChanged = "user.password_changed"
+)
+
+// Event is also the envelope sent to brokers. Id is the dedupe ID and
+// Key orders events of one aggregate on brokers that partition by key.
+type Event struct {
+	Id        uuid.UUID       `json:"id"`
+	Topic     string          `json:"topic"`
+	Key       string          `json:"key"`
+	Payload   json.RawMessage `json:"payload"`
+	CreatedAt time.Time       `json:"created_at"`
+	Attempts  int             `json:"-"`
+}
+
+func NewEvent(topic, key string, payload any) (Event, error) {
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return Event{}, fmt.Errorf("failed to encode %s event: %w", topic, err)
+	}
+
+	return Event{
+		Id:        uuid.New(),
+		Topic:     topic,
+		Key:       key,
+		Payload:   raw,
+		CreatedAt: time.Now().UTC(),
+	}, nil
+}
+
+// Publisher delivers one event to a broker. It returns only once the
+// broker has accepted the event.
+type Publisher interface {
+	Publish(ctx context.Context, e Event) error
+}
+
+// Store is the relay's view of the outbox table.
+type Store interface {
+	// Claim leases up to limit due events until leaseUntil, so that other
+	// relays skip them, and counts the attempt.
+	Claim(ctx context.Context, limit int, leaseUntil time.Time) ([]Event, error)
+	MarkPublished(ctx context.Context, id uuid.UUID) error
+	MarkFailed(ctx context.Context, id uuid.UUID, cause error, retryAt time.Time) error
+	// Prune deletes events published before the given time.
+	Prune(ctx context.Context, before time.Time) (int64, error)
+}
@@ -0,0 +1,95 @@
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type postgresStore struct {
+	db *sql.DB
+}
+
+func NewPostgres(db *sql.DB) Store {
+	return &postgresStore{db: db}
+}
+
+// Insert writes e inside tx, the transaction of the change it describes.
+// The event is due right away; next_attempt_at is set from the same clock
+// Claim compares it with.
+func Insert(tx *sql.Tx, e Event) error {
+	_, err := tx.Exec(`INSERT INTO outbox(id, topic, key, payload, created_at, next_attempt_at) VALUES($1, $2, $3, $4, $5, $5)`,
+		e.Id, e.Topic, e.Key, string(e.Payload), e.CreatedAt.UTC())
+	if err != nil {
+		return fmt.Errorf("failed to write %s event to outbox: %w", e.Topic, err)
+	}
+	return nil
+}
+
+func (s *postgresStore) Claim(ctx context.Context, limit int, leaseUntil time.Time) ([]Event, error) {
+	rows, err := s.db.QueryContext(ctx, `UPDATE outbox SET next_attempt_at = $2, attempts = attempts + 1
+		WHERE id IN (
+			SELECT id FROM outbox
+			WHERE published_at IS NULL AND next_attempt_at <= $1
+			ORDER BY created_at
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, topic, key, payload, created_at, attempts`,
+		time.Now().UTC(), leaseUntil.UTC(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim outbox events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []Event
+	for rows.Next() {
+		var e Event
+		var payload []byte
+		if err := rows.Scan(&e.Id, &e.Topic, &e.Key, &payload, &e.CreatedAt, &e.Attempts); err != nil {
+			return nil, fmt.Errorf("failed to read outbox event: %w", err)
+		}
+		e.Payload = payload
+		events = append(events, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to claim outbox events: %w", err)
+	}
+
+	// RETURNING does not keep the subquery order
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].CreatedAt.Before(events[j].CreatedAt)
+	})
+	return events, nil
+}
+
+func (s *postgresStore) MarkPublished(ctx context.Context, id uuid.UUID) error {
+	_, err := s.db.ExecContext(ctx, `UPDATE outbox SET published_at = $2, last_error = '' WHERE id = $1`, id, time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("failed to mark outbox event %s published: %w", id, err)
+	}
+	return nil
+}
+
+func (s *postgresStore) MarkFailed(ctx context.Context, id uuid.UUID, cause error, retryAt time.Time) error {
+	_, err := s.db.ExecContext(ctx, `UPDATE outbox SET last_error = $2, next_attempt_at = $3 WHERE id = $1`,
+		id, cause.Error(), retryAt.UTC())
+	if err != nil {
+		return fmt.Errorf("failed to reschedule outbox event %s: %w", id, err)
+	}
+	return nil
+}
+
+func (s *postgresStore) Prune(ctx context.Context, before time.Time) (int64, error) {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM outbox WHERE published_at < $1`, before.UTC())
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune outbox: %w", err)
+	}
+
+	n, _ := res.RowsAffected()
+	return n, nil
+}
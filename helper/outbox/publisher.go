@@ -0,0 +1,44 @@
+package outbox
+
+import (
+	"context"
+	"log/slog"
+)
+
+type logPublisher struct {
+	logger *slog.Logger
+}
+
+// NewLogPublisher writes every event to the log instead of a broker.
+func NewLogPublisher(logger *slog.Logger) Publisher {
+	return &logPublisher{logger: logger}
+}
+
+func (p *logPublisher) Publish(_ context.Context, e Event) error {
+	p.logger.Info("outbox event",
+		slog.String("event_id", e.Id.String()),
+		slog.String("topic", e.Topic),
+		slog.String("key", e.Key),
+		slog.String("payload", string(e.Payload)),
+	)
+	return nil
+}
+
+type channelPublisher struct {
+	ch chan<- Event
+}
+
+// NewChannelPublisher hands events to an in-process consumer. Publish
+// blocks until the consumer receives the event or ctx is done.
+func NewChannelPublisher(ch chan<- Event) Publisher {
+	return &channelPublisher{ch: ch}
+}
+
+func (p *channelPublisher) Publish(ctx context.Context, e Event) error {
+	select {
+	case p.ch <- e:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
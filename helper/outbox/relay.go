@@ -0,0 +1,127 @@
+package outbox
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+type RelayConfig struct {
+	BatchSize    int
+	PollInterval time.Duration
+	// Lease is how long a claimed event is hidden from other relays while
+	// it is being published.
+	Lease time.Duration
+	// MaxBackoff caps the exponential retry delay of a failing event.
+	MaxBackoff time.Duration
+	// Retention is how long published events are kept; zero keeps them.
+	Retention time.Duration
+}
+
+// pruneInterval is how often published events past retention are removed.
+const pruneInterval = time.Hour
+
+type Relay struct {
+	store     Store
+	publisher Publisher
+	cfg       RelayConfig
+}
+
+func NewRelay(store Store, publisher Publisher, cfg RelayConfig) *Relay {
+	return &Relay{store: store, publisher: publisher, cfg: cfg}
+}
+
+// Run publishes due events every poll interval until ctx is done. Several
+// relays may share one outbox; claims keep them from publishing the same
+// event concurrently.
+func (r *Relay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.cfg.PollInterval)
+	defer ticker.Stop()
+
+	var lastPrune time.Time
+	for {
+		r.Drain(ctx)
+
+		if r.cfg.Retention > 0 && time.Since(lastPrune) >= pruneInterval {
+			r.prune(ctx)
+			lastPrune = time.Now()
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Drain publishes batches until no due event is left and returns how many
+// events were published.
+func (r *Relay) Drain(ctx context.Context) int {
+	published := 0
+	for ctx.Err() == nil {
+		events, err := r.store.Claim(ctx, r.cfg.BatchSize, time.Now().Add(r.cfg.Lease))
+		if err != nil {
+			slog.Warn("failed to claim outbox events", slog.Any("error", err))
+			return published
+		}
+
+		for _, e := range events {
+			if r.publish(ctx, e) {
+				published++
+			}
+		}
+
+		if len(events) < r.cfg.BatchSize {
+			break
+		}
+	}
+	return published
+}
+
+func (r *Relay) publish(ctx context.Context, e Event) bool {
+	if err := r.publisher.Publish(ctx, e); err != nil {
+		retryAt := time.Now().Add(r.backoff(e.Attempts))
+		slog.Warn("failed to publish outbox event",
+			slog.String("event_id", e.Id.String()),
+			slog.String("topic", e.Topic),
+			slog.Int("attempts", e.Attempts),
+			slog.Time("retry_at", retryAt),
+			slog.Any("error", err),
+		)
+		if err := r.store.MarkFailed(ctx, e.Id, err, retryAt); err != nil {
+			slog.Warn("failed to reschedule outbox event", slog.Any("error", err))
+		}
+		return false
+	}
+
+	// if this fails the event is published again after the lease; the
+	// dedupe ID lets consumers drop the copy
+	if err := r.store.MarkPublished(ctx, e.Id); err != nil {
+		slog.Warn("failed to mark outbox event published", slog.Any("error", err))
+	}
+	return true
+}
+
+// backoff doubles from one second per attempt, up to MaxBackoff.
+func (r *Relay) backoff(attempts int) time.Duration {
+	delay := time.Second
+	for i := 1; i < attempts && delay < r.cfg.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > r.cfg.MaxBackoff {
+		delay = r.cfg.MaxBackoff
+	}
+	return delay
+}
+
+func (r *Relay) prune(ctx context.Context) {
+	n, err := r.store.Prune(ctx, time.Now().Add(-r.cfg.Retention))
+	if err != nil {
+		slog.Warn("failed to prune outbox", slog.Any("error", err))
+		return
+	}
+	if n > 0 {
+		slog.Info("pruned published outbox events", slog.Int64("deleted", n))
+	}
+}
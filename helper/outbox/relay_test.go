@@ -0,0 +1,158 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+var testRelayConfig = RelayConfig{
+	BatchSize:  2,
+	Lease:      time.Minute,
+	MaxBackoff: 10 * time.Second,
+}
+
+func addEvents(t *testing.T, store *MemoryStore, n int) []Event {
+	t.Helper()
+
+	var events []Event
+	for i := 0; i < n; i++ {
+		e, err := NewEvent(TopicUserRegistered, "user-1", map[string]int{"n": i})
+		if err != nil {
+			t.Fatalf("NewEvent: %v", err)
+		}
+		// distinct, increasing creation times keep the order deterministic
+		e.CreatedAt = e.CreatedAt.Add(time.Duration(i-n) * time.Millisecond)
+		store.Add(e)
+		events = append(events, e)
+	}
+	return events
+}
+
+type failingPublisher struct{}
+
+func (failingPublisher) Publish(context.Context, Event) error {
+	return errors.New("broker unreachable")
+}
+
+func TestRelayDrain(t *testing.T) {
+	store := NewMemory()
+	events := addEvents(t, store, 5)
+
+	ch := make(chan Event, len(events))
+	relay := NewRelay(store, NewChannelPublisher(ch), testRelayConfig)
+
+	if n := relay.Drain(context.Background()); n != len(events) {
+		t.Fatalf("Drain published %d events, want %d", n, len(events))
+	}
+	close(ch)
+
+	i := 0
+	for got := range ch {
+		if got.Id != events[i].Id {
+			t.Fatalf("event %d = %s, want %s in creation order", i, got.Id, events[i].Id)
+		}
+		i++
+	}
+
+	// published events are not claimed again
+	if n := relay.Drain(context.Background()); n != 0 {
+		t.Fatalf("second Drain published %d events, want 0", n)
+	}
+}
+
+func TestRelayRetriesFailedEvents(t *testing.T) {
+	store := NewMemory()
+	events := addEvents(t, store, 1)
+	relay := NewRelay(store, failingPublisher{}, testRelayConfig)
+
+	before := time.Now()
+	if n := relay.Drain(context.Background()); n != 0 {
+		t.Fatalf("Drain published %d events, want 0", n)
+	}
+
+	entry := store.entries[events[0].Id]
+	if entry.publishedAt != nil || entry.lastError != "broker unreachable" {
+		t.Fatalf("entry = %+v, want unpublished with the publish error", entry)
+	}
+	if entry.event.Attempts != 1 || entry.nextAttempt.Before(before.Add(time.Second)) {
+		t.Fatalf("attempts %d retry at %v, want 1 attempt retried after a second", entry.event.Attempts, entry.nextAttempt)
+	}
+
+	// the event waits out its backoff
+	if n := relay.Drain(context.Background()); n != 0 {
+		t.Fatalf("Drain during backoff published %d events, want 0", n)
+	}
+
+	entry.nextAttempt = time.Now()
+	ch := make(chan Event, 1)
+	relay = NewRelay(store, NewChannelPublisher(ch), testRelayConfig)
+	if n := relay.Drain(context.Background()); n != 1 {
+		t.Fatalf("Drain after backoff published %d events, want 1", n)
+	}
+	if got := <-ch; got.Id != events[0].Id || got.Attempts != 2 {
+		t.Fatalf("retried event = %s with %d attempts, want %s with 2", got.Id, got.Attempts, events[0].Id)
+	}
+	if entry.lastError != "" {
+		t.Fatalf("last error %q kept after publishing", entry.lastError)
+	}
+}
+
+func TestRelayChannelPublisherHonoursContext(t *testing.T) {
+	store := NewMemory()
+	addEvents(t, store, 1)
+
+	// nobody receives, so Publish gives up when ctx is done
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	relay := NewRelay(store, NewChannelPublisher(make(chan Event)), testRelayConfig)
+	if n := relay.Drain(ctx); n != 0 {
+		t.Fatalf("Drain published %d events, want 0", n)
+	}
+	for _, entry := range store.entries {
+		if entry.lastError != context.DeadlineExceeded.Error() {
+			t.Fatalf("last error = %q, want %q", entry.lastError, context.DeadlineExceeded)
+		}
+	}
+}
+
+func TestRelayBackoff(t *testing.T) {
+	relay := NewRelay(NewMemory(), failingPublisher{}, testRelayConfig)
+
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{4, 8 * time.Second},
+		{5, 10 * time.Second},
+		{50, 10 * time.Second},
+	}
+
+	for _, tt := range tests {
+		if got := relay.backoff(tt.attempts); got != tt.want {
+			t.Errorf("backoff(%d) = %s, want %s", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+func TestRelayPrune(t *testing.T) {
+	store := NewMemory()
+	events := addEvents(t, store, 2)
+
+	old := time.Now().Add(-2 * time.Hour)
+	store.entries[events[0].Id].publishedAt = &old
+
+	relay := NewRelay(store, failingPublisher{}, RelayConfig{Retention: time.Hour})
+	relay.prune(context.Background())
+
+	if _, ok := store.entries[events[0].Id]; ok {
+		t.Fatalf("event published before the retention window was kept")
+	}
+	if _, ok := store.entries[events[1].Id]; !ok {
+		t.Fatalf("unpublished event was pruned")
+	}
+}
@@ -1,14 +1,19 @@
 package response
 
 import (
+	"github.com/Reza1878/goesclearning/user-service/helper/i18n"
 	"github.com/Reza1878/goesclearning/user-service/model"
 	"github.com/gin-gonic/gin"
 )
 
+const MsgSuccess = "response.success"
+
+// JSON writes a success body. message may be a catalogue key, in which
+// case it is translated to the request locale.
 func JSON(ctx *gin.Context, statusCode int, message string, data interface{}) {
 	ctx.JSON(statusCode, model.ResponseSuccess{
 		StatusCode: statusCode,
-		Message:    message,
+		Message:    i18n.T(ctx, message, nil),
 		Data:       data,
 	})
 }
@@ -0,0 +1,63 @@
+package username
+
+import (
+	"strconv"
+	"strings"
+)
+
+const (
+	MinLength = 3
+	MaxLength = 30
+)
+
+// reserved handles could be mistaken for the service itself or collide
+// with route names.
+var reserved = map[string]bool{
+	"about": true, "account": true, "admin": true, "administrator": true,
+	"anonymous": true, "api": true, "help": true, "login": true,
+	"logout": true, "mail": true, "me": true, "moderator": true,
+	"null": true, "official": true, "product": true, "products": true,
+	"register": true, "root": true, "security": true, "settings": true,
+	"signin": true, "signup": true, "staff": true, "support": true,
+	"system": true, "undefined": true, "user": true, "users": true,
+	"www": true,
+}
+
+// Problem is the rule a username breaks. Rule matches a validation
+// catalogue key and Param is its parameter.
+type Problem struct {
+	Rule  string
+	Param string
+}
+
+// Normalize returns the stored form of a username. Usernames are
+// case-insensitive.
+func Normalize(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// Check validates a normalised username: 3 to 30 lowercase letters,
+// digits or underscores, starting with a letter, and not reserved.
+func Check(name string) *Problem {
+	switch {
+	case len(name) < MinLength:
+		return &Problem{Rule: "min", Param: strconv.Itoa(MinLength)}
+	case len(name) > MaxLength:
+		return &Problem{Rule: "max", Param: strconv.Itoa(MaxLength)}
+	}
+
+	for i, r := range name {
+		letter := r >= 'a' && r <= 'z'
+		if i == 0 && !letter {
+			return &Problem{Rule: "username"}
+		}
+		if !letter && !(r >= '0' && r <= '9') && r != '_' {
+			return &Problem{Rule: "username"}
+		}
+	}
+
+	if reserved[name] {
+		return &Problem{Rule: "reserved"}
+	}
+	return nil
+}
@@ -1,62 +1,210 @@
 package main
 
 import (
+	"context"
+	"crypto/sha256"
 	"database/sql"
-	"log"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
 
 	"github.com/Reza1878/goesclearning/user-service/config"
+	auditHandlers "github.com/Reza1878/goesclearning/user-service/handler/audit"
 	productHandlers "github.com/Reza1878/goesclearning/user-service/handler/product"
 	handlers "github.com/Reza1878/goesclearning/user-service/handler/user"
+	"github.com/Reza1878/goesclearning/user-service/helper/audit"
+	"github.com/Reza1878/goesclearning/user-service/helper/breaker"
+	"github.com/Reza1878/goesclearning/user-service/helper/cache"
+	"github.com/Reza1878/goesclearning/user-service/helper/email"
+	"github.com/Reza1878/goesclearning/user-service/helper/fault"
+	"github.com/Reza1878/goesclearning/user-service/helper/jwt"
+	"github.com/Reza1878/goesclearning/user-service/helper/logger"
+	"github.com/Reza1878/goesclearning/user-service/helper/outbox"
 	"github.com/Reza1878/goesclearning/user-service/proto/product"
+	"github.com/Reza1878/goesclearning/user-service/proto/product/fake"
+	eventRepo "github.com/Reza1878/goesclearning/user-service/repository/loginevent"
+	sessionRepo "github.com/Reza1878/goesclearning/user-service/repository/session"
 	repository "github.com/Reza1878/goesclearning/user-service/repository/user"
 	"github.com/Reza1878/goesclearning/user-service/routes"
 	productUC "github.com/Reza1878/goesclearning/user-service/usecases/product"
 	usecases "github.com/Reza1878/goesclearning/user-service/usecases/user"
 
-	"github.com/redis/go-redis/v9"
 	"google.golang.org/grpc"
 )
 
 func main() {
-	cfg, err := config.Load()
-	if err != nil {
+	args := os.Args[1:]
+
+	if len(args) >= 2 && args[0] == "config" && args[1] == "print" {
+		if err := config.Print(os.Stdout, args[2:]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if len(args) >= 1 && args[0] == "verify-audit" {
+		ok, err := verifyAudit(os.Stdout, args[1:])
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		if !ok {
+			os.Exit(2)
+		}
+		return
+	}
+
+	if len(args) >= 2 && args[0] == "users" && args[1] == "duplicate-emails" {
+		if err := reportDuplicateEmails(os.Stdout, args[2:]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	cfg, err := config.Load(args)
+	if errors.Is(err, config.ErrHelp) {
 		return
 	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	logger.Init(cfg.Log)
+	fault.UseProblemJSON(cfg.ErrorFormat == "problem")
+	jwt.SetSigningKey([]byte(cfg.JWT.Secret))
+	email.SetFolding(email.Folding(cfg.EmailFolding))
 
 	db, err := config.InitPostgreSQL(cfg.Postgres)
 	if err != nil {
-		log.Default().Printf("[ERROR] %v", err)
-		return
+		slog.Error("failed to initialize dependency", slog.Any("error", err))
+		os.Exit(1)
 	}
 	defer db.Close()
 
-	redis, err := config.InitRedis(cfg.Redis)
+	store, err := initCache(cfg)
 	if err != nil {
-		log.Default().Printf("[ERROR] %v", err)
-		return
+		slog.Error("failed to initialize dependency", slog.Any("error", err))
+		os.Exit(1)
 	}
 
-	rpc, err := config.RPCDial(cfg.Grpc)
+	rpc, err := initProductRPC(cfg)
 	if err != nil {
-		return
+		slog.Error("failed to initialize dependency", slog.Any("error", err))
+		os.Exit(1)
+	}
+	defer rpc.Close()
+
+	if cfg.Outbox.RelayEnabled {
+		publisher, err := initPublisher(cfg.Outbox)
+		if err != nil {
+			slog.Error("failed to initialize dependency", slog.Any("error", err))
+			os.Exit(1)
+		}
+		relay := outbox.NewRelay(outbox.NewPostgres(db), publisher, cfg.Outbox.Relay)
+		go relay.Run(context.Background())
 	}
 
-	routes := initDepedencies(db, rpc, redis)
+	routes, err := initDepedencies(cfg, db, rpc, store)
+	if err != nil {
+		slog.Error("failed to initialize dependency", slog.Any("error", err))
+		os.Exit(1)
+	}
+	routes.BaseURL = cfg.BaseURLPath
+	routes.CORS = cfg.CORS
+	routes.TrustedProxies = cfg.TrustedProxies
+	routes.Limits = store
+	routes.RateLimit = cfg.RateLimit
 	routes.SetupRoutes()
 	routes.Run(cfg.Port)
 }
 
-func initDepedencies(db *sql.DB, rpc *grpc.ClientConn, redis *redis.Client) *routes.Routes {
-	userRepo := repository.NewStore(db)
-	userUC := usecases.NewUserUsecase(userRepo, redis)
+func initProductRPC(cfg *config.Config) (*grpc.ClientConn, error) {
+	if !cfg.FakeProduct {
+		return config.RPCDial(cfg.Grpc)
+	}
+
+	slog.Warn("serving products from the in-memory fake product service")
+	conn, _, err := fake.Dial(fake.NewServer())
+	return conn, err
+}
+
+func initPublisher(cfg config.OutboxConfig) (outbox.Publisher, error) {
+	switch cfg.Publisher {
+	case "nats":
+		return outbox.NewNATSPublisher(cfg.NATSURL, cfg.PublishTimeout)
+	case "kafka":
+		return outbox.NewKafkaRESTPublisher(cfg.KafkaRESTURL, &http.Client{Timeout: cfg.PublishTimeout})
+	default:
+		return outbox.NewLogPublisher(slog.Default()), nil
+	}
+}
+
+func initCache(cfg *config.Config) (cache.Store, error) {
+	if cfg.Cache.Backend == "memory" {
+		slog.Warn("using the in-memory cache; idempotency keys and cache invalidation are not shared between instances")
+		return cache.NewMemory(cfg.Cache.MemoryMaxEntries), nil
+	}
+
+	redis, err := config.InitRedis(cfg.Redis)
+	if err != nil {
+		return nil, err
+	}
+	return cache.NewRedis(redis), nil
+}
+
+func initDepedencies(cfg *config.Config, db *sql.DB, rpc *grpc.ClientConn, store cache.Store) (*routes.Routes, error) {
+	var userRepo repository.UserRepository = repository.NewStore(db)
+	if cfg.Cache.UserEnabled {
+		// password hashes are sealed in the cache with a key derived from
+		// the JWT secret, so rotating the secret also voids the cache
+		cacheKey := sha256.Sum256([]byte("user-cache:" + cfg.JWT.Secret))
+
+		cached, err := repository.NewCachedStore(userRepo, store, cacheKey[:], cfg.Cache.UserTTL, cfg.Cache.UserNegativeTTL)
+		if err != nil {
+			return nil, err
+		}
+		userRepo = cached
+	}
+	userUC := usecases.NewUserUsecase(userRepo, sessionRepo.NewStore(db), eventRepo.NewStore(db))
+	if cfg.LoginEvents.Retention > 0 {
+		go userUC.RunEventRetention(context.Background(), cfg.LoginEvents.Retention, cfg.LoginEvents.PruneInterval)
+	}
 	userHandler := handlers.NewHandler(userUC)
 
 	productRPC := product.NewProductServiceClient(rpc)
-	productUC := productUC.NewProductUsecase(productRPC)
-	productHandler := productHandlers.NewProductUsecase(productUC)
+	if cfg.Breaker.Enabled {
+		productBreaker := breaker.New("product-service", cfg.Breaker.Breaker)
+		productBreaker.OnStateChange(func(name string, from, to breaker.State) {
+			slog.Warn("circuit breaker state changed",
+				slog.String("breaker", name),
+				slog.String("from", from.String()),
+				slog.String("to", to.String()),
+			)
+		})
 
-	return &routes.Routes{
-		User:    userHandler,
-		Product: productHandler,
+		productRPC = productUC.NewCircuitBreakerClient(productRPC, productBreaker, productUC.StaleFallback{
+			Enabled:    cfg.Breaker.StaleFallback,
+			TTL:        cfg.Breaker.StaleTTL,
+			MaxEntries: cfg.Breaker.StaleMaxEntries,
+		})
+	}
+	auditLog := audit.New(audit.NewPostgres(db))
+
+	var productUsecase productUC.ProductUsecases = productUC.NewProductUsecase(productRPC, store, auditLog)
+	if cfg.Cache.ProductEnabled {
+		productUsecase = productUC.NewCachedProductUsecase(productUsecase, store, cfg.Cache.ProductTTL)
 	}
+	productHandler := productHandlers.NewProductUsecase(productUsecase)
+
+	return &routes.Routes{
+		Sessions: userUC,
+		User:     userHandler,
+		Product:  productHandler,
+		Audit:    auditHandlers.NewHandler(auditLog),
+	}, nil
 }
@@ -0,0 +1,34 @@
+package middlewares
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/Reza1878/goesclearning/user-service/helper/fault"
+	"github.com/Reza1878/goesclearning/user-service/helper/jwt"
+	"github.com/gin-gonic/gin"
+)
+
+// RequireAdmin lets only admins through. It must run after Authenticate.
+func RequireAdmin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, err := jwt.ClaimsFromContext(c)
+		if err != nil {
+			fault.Response(c, err)
+			c.Abort()
+			return
+		}
+
+		if !claims.IsAdmin() {
+			fault.Response(c, fault.Custom(
+				http.StatusForbidden,
+				fault.ErrForbidden,
+				fmt.Sprintf("user %s is not an admin", claims.UserId),
+			))
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
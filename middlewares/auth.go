@@ -0,0 +1,57 @@
+package middlewares
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/Reza1878/goesclearning/user-service/helper/fault"
+	"github.com/Reza1878/goesclearning/user-service/helper/jwt"
+	"github.com/Reza1878/goesclearning/user-service/helper/logger"
+	"github.com/gin-gonic/gin"
+)
+
+// SessionChecker rejects tokens whose login session is no longer valid.
+type SessionChecker interface {
+	CheckSession(claims *jwt.JWTPayload) error
+}
+
+func Authenticate(sessions SessionChecker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token, err := jwt.GetTokenFromHeader(c)
+		if err != nil {
+			fault.Response(c, err)
+			c.Abort()
+			return
+		}
+
+		claims, err := jwt.GetClaims(token)
+		if err != nil {
+			fault.Response(c, err)
+			c.Abort()
+			return
+		}
+
+		// refresh tokens, and tokens issued before the typ claim, cannot
+		// authenticate requests
+		if claims.Type != jwt.TypeAccess {
+			fault.Response(c, fault.Custom(
+				http.StatusUnauthorized,
+				fault.ErrUnauthorized,
+				fmt.Sprintf("token of type %q is not an access token", claims.Type),
+			))
+			c.Abort()
+			return
+		}
+
+		if err := sessions.CheckSession(claims); err != nil {
+			fault.Response(c, err)
+			c.Abort()
+			return
+		}
+
+		c.Set(jwt.ClaimsKey, claims)
+		logger.SetUserID(c, claims.UserId)
+
+		c.Next()
+	}
+}
@@ -0,0 +1,61 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Reza1878/goesclearning/user-service/helper/jwt"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type allowSessions struct{}
+
+func (allowSessions) CheckSession(*jwt.JWTPayload) error { return nil }
+
+func TestAuthenticateAcceptsOnlyAccessTokens(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	jwt.SetSigningKey([]byte("test-signing-key"))
+
+	subject := jwt.Subject{UserId: uuid.NewString(), SessionId: uuid.NewString(), FamilyId: uuid.NewString()}
+	access, _, err := jwt.CreateAccessToken(subject)
+	if err != nil {
+		t.Fatalf("CreateAccessToken: %v", err)
+	}
+	refresh, _, err := jwt.CreateRefreshToken(subject, uuid.New())
+	if err != nil {
+		t.Fatalf("CreateRefreshToken: %v", err)
+	}
+
+	router := gin.New()
+	router.GET("/", Authenticate(allowSessions{}), func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+
+	tests := []struct {
+		name  string
+		token string
+		want  int
+	}{
+		{"access token", *access, http.StatusNoContent},
+		{"refresh token", *refresh, http.StatusUnauthorized},
+		{"no token", "", http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.want, rec.Body.String())
+			}
+		})
+	}
+}
@@ -1,20 +1,228 @@
 package middlewares
 
-import "github.com/gin-gonic/gin"
+import (
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Reza1878/goesclearning/user-service/config"
+	"github.com/gin-gonic/gin"
+)
+
+type corsPolicy struct {
+	origins          []originPattern
+	anyOrigin        bool
+	methods          map[string]bool
+	headers          map[string]bool
+	allowMethods     string
+	allowHeaders     string
+	exposeHeaders    string
+	maxAge           string
+	allowCredentials bool
+}
+
+type originPattern struct {
+	scheme string
+	host   string
+	// wildcard is set for "*.example.com" patterns, which match any
+	// subdomain but not the apex domain itself. Without a port in the
+	// pattern, any port matches.
+	wildcard bool
+	hasPort  bool
+}
+
+type groupPolicy struct {
+	prefix string
+	policy *corsPolicy
+}
+
+// CORS answers preflights and decorates cross-origin responses according
+// to cfg. groups maps a full path prefix to the policy that overrides the
+// default for it; the longest matching prefix wins.
+func CORS(cfg config.CORSPolicy, groups map[string]config.CORSPolicy) gin.HandlerFunc {
+	defaultPolicy := newCORSPolicy(cfg)
+
+	overrides := make([]groupPolicy, 0, len(groups))
+	for prefix, policy := range groups {
+		overrides = append(overrides, groupPolicy{prefix: prefix, policy: newCORSPolicy(policy)})
+	}
+	sort.Slice(overrides, func(i, j int) bool {
+		return len(overrides[i].prefix) > len(overrides[j].prefix)
+	})
 
-func EnabledCORS() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
-		c.Writer.Header().Set("Access-Control-Max-Age", "86400")
-		c.Writer.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "X-Requested-With, Content-Type, Origin, Authorization, Accept, Client-Security-Token, Accept-Encoding, x-access-token")
-		c.Writer.Header().Set("Access-Control-Expose-Headers", "Content-Length")
-		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-
-		if c.Request.Method == "OPTIONS" {
-			c.AbortWithStatus(200)
+		origin := c.GetHeader("Origin")
+		if origin == "" {
+			c.Next()
+			return
+		}
+
+		policy := defaultPolicy
+		for _, override := range overrides {
+			if matchPrefix(c.Request.URL.Path, override.prefix) {
+				policy = override.policy
+				break
+			}
+		}
+
+		c.Writer.Header().Add("Vary", "Origin")
+		preflight := c.Request.Method == http.MethodOptions && c.GetHeader("Access-Control-Request-Method") != ""
+
+		if !policy.allowOrigin(origin) {
+			if preflight {
+				c.AbortWithStatus(http.StatusForbidden)
+				return
+			}
+			c.Next()
+			return
+		}
+
+		header := c.Writer.Header()
+		if policy.anyOrigin {
+			// a literal * is never honoured for credentialed requests
+			header.Set("Access-Control-Allow-Origin", "*")
 		} else {
+			header.Set("Access-Control-Allow-Origin", origin)
+			if policy.allowCredentials {
+				header.Set("Access-Control-Allow-Credentials", "true")
+			}
+		}
+
+		if !preflight {
+			if policy.exposeHeaders != "" {
+				header.Set("Access-Control-Expose-Headers", policy.exposeHeaders)
+			}
 			c.Next()
+			return
+		}
+
+		header.Add("Vary", "Access-Control-Request-Method")
+		header.Add("Vary", "Access-Control-Request-Headers")
+
+		if !policy.allowPreflight(c.GetHeader("Access-Control-Request-Method"), c.GetHeader("Access-Control-Request-Headers")) {
+			c.AbortWithStatus(http.StatusForbidden)
+			return
+		}
+
+		header.Set("Access-Control-Allow-Methods", policy.allowMethods)
+		if policy.allowHeaders != "" {
+			header.Set("Access-Control-Allow-Headers", policy.allowHeaders)
+		}
+		if policy.maxAge != "" {
+			header.Set("Access-Control-Max-Age", policy.maxAge)
+		}
+		c.AbortWithStatus(http.StatusNoContent)
+	}
+}
+
+func newCORSPolicy(cfg config.CORSPolicy) *corsPolicy {
+	p := &corsPolicy{
+		methods:          map[string]bool{},
+		headers:          map[string]bool{},
+		allowMethods:     strings.Join(cfg.AllowedMethods, ", "),
+		allowHeaders:     strings.Join(cfg.AllowedHeaders, ", "),
+		exposeHeaders:    strings.Join(cfg.ExposedHeaders, ", "),
+		allowCredentials: cfg.AllowCredentials,
+	}
+
+	if cfg.MaxAge > 0 {
+		p.maxAge = strconv.Itoa(int(cfg.MaxAge.Seconds()))
+	}
+
+	for _, method := range cfg.AllowedMethods {
+		p.methods[strings.ToUpper(method)] = true
+	}
+	for _, h := range cfg.AllowedHeaders {
+		p.headers[strings.ToLower(h)] = true
+	}
+
+	for _, origin := range cfg.AllowedOrigins {
+		if origin == "*" {
+			p.anyOrigin = true
+			continue
+		}
+		p.origins = append(p.origins, parseOriginPattern(origin))
+	}
+
+	return p
+}
+
+func parseOriginPattern(origin string) originPattern {
+	var pattern originPattern
+
+	origin = strings.ToLower(strings.TrimSuffix(origin, "/"))
+	if scheme, host, ok := strings.Cut(origin, "://"); ok {
+		pattern.scheme = scheme
+		origin = host
+	}
+
+	if strings.HasPrefix(origin, "*.") {
+		pattern.wildcard = true
+		origin = origin[1:]
+	}
+	pattern.host = origin
+	_, _, err := net.SplitHostPort(origin)
+	pattern.hasPort = err == nil
+
+	return pattern
+}
+
+func (p *corsPolicy) allowOrigin(origin string) bool {
+	if p.anyOrigin {
+		return true
+	}
+
+	scheme, host, ok := strings.Cut(strings.ToLower(origin), "://")
+	if !ok {
+		return false
+	}
+	hostname := host
+	if name, _, err := net.SplitHostPort(host); err == nil {
+		hostname = name
+	}
+
+	for _, pattern := range p.origins {
+		if pattern.scheme != "" && pattern.scheme != scheme {
+			continue
+		}
+		if pattern.wildcard {
+			candidate := hostname
+			if pattern.hasPort {
+				candidate = host
+			}
+			if strings.HasSuffix(candidate, pattern.host) && len(candidate) > len(pattern.host) {
+				return true
+			}
+			continue
 		}
+		if host == pattern.host {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (p *corsPolicy) allowPreflight(method, headers string) bool {
+	if !p.methods[strings.ToUpper(method)] {
+		return false
+	}
+
+	for _, h := range strings.Split(headers, ",") {
+		h = strings.ToLower(strings.TrimSpace(h))
+		if h != "" && !p.headers[h] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func matchPrefix(path, prefix string) bool {
+	if !strings.HasPrefix(path, prefix) {
+		return false
 	}
+	return len(path) == len(prefix) || path[len(prefix)] == '/' || strings.HasSuffix(prefix, "/")
 }
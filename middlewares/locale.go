@@ -0,0 +1,26 @@
+package middlewares
+
+import (
+	"github.com/Reza1878/goesclearning/user-service/helper/i18n"
+	"github.com/gin-gonic/gin"
+)
+
+// Locale resolves the response language. An explicit lang query
+// parameter or cookie (the user's preference) wins over Accept-Language.
+func Locale() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		locale := c.Query("lang")
+		if locale == "" {
+			locale, _ = c.Cookie("lang")
+		}
+		if !i18n.Supported(locale) {
+			locale = i18n.Match(c.GetHeader("Accept-Language"))
+		}
+
+		c.Set(i18n.LocaleKey, locale)
+		c.Request = c.Request.WithContext(i18n.WithLocale(c.Request.Context(), locale))
+		c.Header("Content-Language", locale)
+
+		c.Next()
+	}
+}
@@ -1,21 +1,49 @@
 package middlewares
 
 import (
-	"fmt"
+	"log/slog"
+	"time"
 
+	"github.com/Reza1878/goesclearning/user-service/helper/logger"
 	"github.com/gin-gonic/gin"
 )
 
-func Logger(router *gin.Engine) gin.HandlerFunc {
-	return gin.LoggerWithFormatter(func(param gin.LogFormatterParams) string {
-		return fmt.Sprintf("[%s] | %d | %v | %s | %s %s | %s | %s\n",
-			param.TimeStamp.Format("2006-01-02 15:04:05"),
-			param.StatusCode,
-			param.Latency,
-			param.ClientIP,
-			param.Method, param.Path,
-			param.Request.UserAgent(),
-			param.ErrorMessage,
-		)
-	})
+func Logger() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		path := c.Request.URL.Path
+		if raw := c.Request.URL.RawQuery; raw != "" {
+			path = path + "?" + raw
+		}
+
+		c.Next()
+
+		status := c.Writer.Status()
+		attrs := []slog.Attr{
+			slog.Int("status", status),
+			slog.Duration("latency", time.Since(start)),
+			slog.String("client_ip", c.ClientIP()),
+			slog.String("method", c.Request.Method),
+			slog.String("path", path),
+			slog.String("user_agent", c.Request.UserAgent()),
+		}
+
+		if userID := c.GetString(logger.UserIDKey); userID != "" {
+			attrs = append(attrs, slog.String(logger.UserIDKey, userID))
+		}
+
+		if errs := c.Errors.ByType(gin.ErrorTypePrivate).String(); errs != "" {
+			attrs = append(attrs, slog.String("error", errs))
+		}
+
+		level := slog.LevelInfo
+		switch {
+		case status >= 500:
+			level = slog.LevelError
+		case status >= 400:
+			level = slog.LevelWarn
+		}
+
+		logger.FromContext(c).LogAttrs(c.Request.Context(), level, "request", attrs...)
+	}
 }
@@ -0,0 +1,65 @@
+package middlewares
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Reza1878/goesclearning/user-service/helper/cache"
+	"github.com/Reza1878/goesclearning/user-service/helper/fault"
+	"github.com/Reza1878/goesclearning/user-service/helper/logger"
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimit allows limit requests per client IP in each fixed window.
+// The counters live in store so that replicas share them. When the store
+// is unreachable the request is let through.
+func RateLimit(store cache.Store, name string, limit int, window time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		now := time.Now()
+		index := now.UnixNano() / int64(window)
+		key := fmt.Sprintf("ratelimit:%s:%s:%d", name, c.ClientIP(), index)
+
+		count, err := hit(c, store, key, window)
+		if err != nil {
+			logger.FromContext(c).Warn("rate limiter unavailable, allowing request",
+				slog.String("limiter", name),
+				slog.Any("error", err),
+			)
+			c.Next()
+			return
+		}
+
+		remaining := int64(limit) - count
+		if remaining < 0 {
+			remaining = 0
+		}
+		c.Header("X-RateLimit-Limit", strconv.Itoa(limit))
+		c.Header("X-RateLimit-Remaining", strconv.FormatInt(remaining, 10))
+
+		if count > int64(limit) {
+			resetAt := time.Unix(0, (index+1)*int64(window))
+			fault.Response(c, fault.Custom(
+				http.StatusTooManyRequests,
+				fault.ErrTooManyRequests,
+				fmt.Sprintf("rate limit %s exceeded by %s: %d requests in %s", name, c.ClientIP(), count, window),
+			).WithRetryAfter(resetAt.Sub(now)))
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// hit counts one request against key. The key is created with the window
+// as TTL first because Incr keeps, but never sets, the expiry.
+func hit(c *gin.Context, store cache.Store, key string, window time.Duration) (int64, error) {
+	ctx := c.Request.Context()
+	if _, err := store.SetNX(ctx, key, []byte("0"), window); err != nil {
+		return 0, err
+	}
+	return store.Incr(ctx, key)
+}
@@ -0,0 +1,3 @@
+-- Emails are unique regardless of case. This fails if the table already
+-- holds addresses that differ only in case; merge those accounts first.
+CREATE UNIQUE INDEX IF NOT EXISTS users_email_lower_key ON users (LOWER(email));
@@ -0,0 +1,3 @@
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_email_length_check;
+ALTER TABLE users ALTER COLUMN email TYPE VARCHAR(100);
+CREATE UNIQUE INDEX IF NOT EXISTS users_email_lower_key ON users (LOWER(email));
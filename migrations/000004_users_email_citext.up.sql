@@ -0,0 +1,7 @@
+CREATE EXTENSION IF NOT EXISTS citext;
+
+-- citext makes users_email_key and every email comparison
+-- case-insensitive, so the functional index is no longer needed.
+ALTER TABLE users ALTER COLUMN email TYPE CITEXT;
+ALTER TABLE users ADD CONSTRAINT users_email_length_check CHECK (char_length(email) <= 100);
+DROP INDEX IF EXISTS users_email_lower_key;
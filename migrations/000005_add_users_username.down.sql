@@ -0,0 +1,5 @@
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_username_format_check;
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_username_key;
+ALTER TABLE users DROP COLUMN IF EXISTS username;
+
+COMMENT ON COLUMN users.name IS NULL;
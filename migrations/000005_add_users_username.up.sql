@@ -0,0 +1,7 @@
+-- name is only a display name and was never unique in the schema; the
+-- handle people log in with is username.
+COMMENT ON COLUMN users.name IS 'display name, not unique';
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS username VARCHAR(30);
+ALTER TABLE users ADD CONSTRAINT users_username_key UNIQUE (username);
+ALTER TABLE users ADD CONSTRAINT users_username_format_check CHECK (username ~ '^[a-z][a-z0-9_]{2,29}$');
@@ -0,0 +1,21 @@
+-- authentication history; user_id is NULL for failed logins to accounts
+-- that do not exist. created_at is TIMESTAMPTZ so pruning by age does not
+-- depend on the session time zone.
+CREATE TABLE IF NOT EXISTS login_events (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID REFERENCES users(id) ON DELETE CASCADE,
+    identifier VARCHAR(254) NOT NULL DEFAULT '',
+    event VARCHAR(30) NOT NULL,
+    reason VARCHAR(50) NOT NULL DEFAULT '',
+    session_id UUID,
+    ip VARCHAR(45) NOT NULL DEFAULT '',
+    user_agent TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT login_events_event_check CHECK (event IN (
+        'register', 'login_success', 'login_failure', 'mfa_challenge',
+        'token_refresh', 'logout', 'password_change'
+    ))
+);
+
+CREATE INDEX IF NOT EXISTS login_events_user_id_idx ON login_events (user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS login_events_created_at_idx ON login_events (created_at);
@@ -0,0 +1,3 @@
+-- the id (jti) of the only refresh token of the session that may still be
+-- used; presenting an older one revokes the session
+ALTER TABLE user_sessions ADD COLUMN IF NOT EXISTS refresh_token_id UUID;
@@ -0,0 +1,15 @@
+package model
+
+import "time"
+
+// AuditQuery filters the admin audit log. From is inclusive, To exclusive.
+type AuditQuery struct {
+	Page       int        `form:"page,default=1" binding:"min=1"`
+	Limit      int        `form:"limit,default=20" binding:"min=1,max=100"`
+	ActorId    string     `form:"actor_id"`
+	Action     string     `form:"action"`
+	TargetType string     `form:"target_type"`
+	TargetId   string     `form:"target_id"`
+	From       *time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To         *time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+}
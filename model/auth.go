@@ -4,13 +4,23 @@ import "time"
 
 type LoginResponse struct {
 	UserData              User       `json:"user_data"`
+	SessionId             string     `json:"session_id"`
 	AccessToken           string     `json:"access_token"`
 	AccessTokenExpiresAt  *time.Time `json:"access_token_expires_at"`
 	RefreshToken          string     `json:"refresh_token"`
 	RefreshTokenExpiresAt *time.Time `json:"refresh_token_expires_at"`
 }
 
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// LoginRequest identifies the account by email or by username. An email
+// field without an @ is treated as a username.
 type LoginRequest struct {
-	Email    string `json:"email" binding:"required"`
+	Email    string `json:"email" binding:"required_without=Username"`
+	Username string `json:"username" binding:"required_without=Email"`
 	Password string `json:"password" binding:"required"`
+	// DeviceName labels the session in the session list.
+	DeviceName string `json:"device_name" binding:"max=100"`
 }
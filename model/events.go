@@ -0,0 +1,11 @@
+package model
+
+import "github.com/google/uuid"
+
+// UserRegisteredEvent is the payload of user.registered.
+type UserRegisteredEvent struct {
+	UserId   uuid.UUID `json:"user_id"`
+	Name     string    `json:"name"`
+	Email    string    `json:"email"`
+	Username string    `json:"username,omitempty"`
+}
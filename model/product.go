@@ -1,12 +1,114 @@
 package model
 
+import "strings"
+
+// Money is an exact amount as a decimal string, e.g. {"amount": "19.99",
+// "currency": "USD"}.
+type Money struct {
+	Amount   string `json:"amount" binding:"required"`
+	Currency string `json:"currency" binding:"required,iso4217"`
+}
+
 type ProductInsertReq struct {
-	Name        string  `json:"name" binding:"required"`
-	Description string  `json:"description" binding:"required"`
-	Price       float32 `json:"price" binding:"required"`
-	Qty         int     `json:"qty" binding:"required"`
+	Name        string `json:"name" binding:"required"`
+	Description string `json:"description" binding:"required"`
+	PriceMoney  *Money `json:"price_money" binding:"required_without=Price,excluded_with=Price"`
+	// Deprecated: use PriceMoney. The float price is still accepted, in
+	// DefaultCurrency, until the next release.
+	Price *float64 `json:"price" binding:"omitempty,gt=0"`
+	Qty   int      `json:"qty" binding:"required,gt=0,lte=1000000"`
 }
 
 type ProductInsertRes struct {
 	Msg string `json:"msg"`
 }
+
+type ProductUpdateReq struct {
+	Name        *string `json:"name" binding:"omitempty,min=1"`
+	Description *string `json:"description" binding:"omitempty,min=1"`
+	PriceMoney  *Money  `json:"price_money" binding:"excluded_with=Price"`
+	// Deprecated: use PriceMoney.
+	Price *float64 `json:"price" binding:"omitempty,gt=0"`
+	Qty   *int     `json:"qty" binding:"omitempty,gte=0,lte=1000000"`
+}
+
+func (r ProductUpdateReq) IsEmpty() bool {
+	return r.Name == nil && r.Description == nil && r.PriceMoney == nil && r.Price == nil && r.Qty == nil
+}
+
+type ReduceProductItem struct {
+	ProductId string `json:"product_id" binding:"required"`
+	Qty       int    `json:"qty" binding:"required,gt=0,lte=10000"`
+}
+
+type ReduceProductReq struct {
+	Items []ReduceProductItem `json:"items" binding:"required,min=1,max=100,dive"`
+}
+
+const MaxListProductIDs = 100
+
+type ProductListFilter struct {
+	Q string `form:"q" binding:"omitempty,max=100"`
+	// MinPrice and MaxPrice are decimal strings in Currency.
+	MinPrice string `form:"min_price"`
+	MaxPrice string `form:"max_price"`
+	Currency string `form:"currency" binding:"omitempty,iso4217"`
+	UserId   string `form:"user_id" binding:"omitempty,uuid"`
+	Sort     string `form:"sort" binding:"omitempty,oneof=price -price name -name created_at -created_at"`
+	IDs      string `form:"ids"`
+}
+
+// ProductIDs splits the comma separated ids parameter, dropping blanks
+// and duplicates.
+func (f ProductListFilter) ProductIDs() []string {
+	if f.IDs == "" {
+		return nil
+	}
+
+	seen := map[string]bool{}
+	var ids []string
+	for _, id := range strings.Split(f.IDs, ",") {
+		id = strings.TrimSpace(id)
+		if id == "" || seen[id] {
+			continue
+		}
+		seen[id] = true
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+type ProductRes struct {
+	Id          string `json:"id"`
+	UserId      string `json:"user_id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Qty         uint32 `json:"qty"`
+	PriceMoney  Money  `json:"price_money"`
+	// Deprecated: use PriceMoney. Kept for clients that still read the
+	// float price.
+	Price float64 `json:"price"`
+}
+
+type ProductMeta struct {
+	TotalData   uint32 `json:"total_data"`
+	TotalPage   uint32 `json:"total_page"`
+	CurrentPage uint32 `json:"current_page"`
+	Limit       uint32 `json:"limit"`
+}
+
+type ProductStats struct {
+	Count    uint32 `json:"count"`
+	TotalQty uint64 `json:"total_qty"`
+	// InventoryValueMoney is nil when the products are priced in more
+	// than one currency.
+	InventoryValueMoney *Money `json:"inventory_value_money,omitempty"`
+	// Deprecated: use InventoryValueMoney.
+	InventoryValue float64 `json:"inventory_value"`
+}
+
+type ProductListRes struct {
+	Items []ProductRes  `json:"items"`
+	Meta  *ProductMeta  `json:"meta,omitempty"`
+	Stats *ProductStats `json:"stats,omitempty"`
+}
@@ -1,8 +1,30 @@
 package model
 
 type ResponseError struct {
-	StatusCode int    `json:"status_code"`
-	Message    string `json:"message"`
+	StatusCode int           `json:"status_code"`
+	Code       string        `json:"code"`
+	Message    string        `json:"message"`
+	RequestID  string        `json:"request_id,omitempty"`
+	Details    []ErrorDetail `json:"details,omitempty"`
+}
+
+type ErrorDetail struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message"`
+}
+
+// ProblemDetails is the RFC 7807 representation of ResponseError.
+type ProblemDetails struct {
+	Type      string        `json:"type"`
+	Title     string        `json:"title"`
+	Status    int           `json:"status"`
+	Detail    string        `json:"detail"`
+	Instance  string        `json:"instance,omitempty"`
+	Code      string        `json:"code"`
+	RequestID string        `json:"request_id,omitempty"`
+	Errors    []ErrorDetail `json:"errors,omitempty"`
 }
 
 type ResponseSuccess struct {
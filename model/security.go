@@ -0,0 +1,62 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Login event types. mfa_challenge and password_change are reserved for
+// the flows that will emit them.
+const (
+	EventRegister       = "register"
+	EventLoginSuccess   = "login_success"
+	EventLoginFailure   = "login_failure"
+	EventMFAChallenge   = "mfa_challenge"
+	EventTokenRefresh   = "token_refresh"
+	EventLogout         = "logout"
+	EventPasswordChange = "password_change"
+)
+
+// Login failure reasons.
+const (
+	ReasonUnknownAccount    = "unknown_account"
+	ReasonInvalidPassword   = "invalid_password"
+	ReasonInvalidIdentifier = "invalid_identifier"
+	// ReasonRefreshTokenReused marks a refresh with a rotated-out token,
+	// which revokes the session.
+	ReasonRefreshTokenReused = "refresh_token_reused"
+)
+
+type LoginEvent struct {
+	Id         uuid.UUID  `json:"id"`
+	UserId     *uuid.UUID `json:"user_id,omitempty"`
+	Identifier string     `json:"identifier,omitempty"`
+	Event      string     `json:"event"`
+	Reason     string     `json:"reason,omitempty"`
+	SessionId  *uuid.UUID `json:"session_id,omitempty"`
+	IP         string     `json:"ip"`
+	UserAgent  string     `json:"user_agent"`
+	CreatedAt  *time.Time `json:"created_at"`
+}
+
+// LoginEventQuery filters the security event feed. UserId is only
+// honoured on the admin feed.
+type LoginEventQuery struct {
+	Page   int    `form:"page,default=1" binding:"min=1"`
+	Limit  int    `form:"limit,default=20" binding:"min=1,max=100"`
+	Event  string `form:"event" binding:"omitempty,oneof=register login_success login_failure mfa_challenge token_refresh logout password_change"`
+	UserId string `form:"user_id" binding:"omitempty,uuid"`
+}
+
+type ListMeta struct {
+	TotalData   int `json:"total_data"`
+	TotalPage   int `json:"total_page"`
+	CurrentPage int `json:"current_page"`
+	Limit       int `json:"limit"`
+}
+
+type LoginEventListRes struct {
+	Items []LoginEvent `json:"items"`
+	Meta  ListMeta     `json:"meta"`
+}
@@ -0,0 +1,33 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Session is one login of a user on one device. It lives as long as its
+// refresh token unless it is revoked first.
+type Session struct {
+	Id       uuid.UUID `json:"id"`
+	UserId   uuid.UUID `json:"-"`
+	FamilyId uuid.UUID `json:"-"`
+	// RefreshTokenId is the ID of the latest refresh token issued for the
+	// session, the only one a refresh accepts.
+	RefreshTokenId uuid.UUID  `json:"-"`
+	DeviceName     string     `json:"device_name"`
+	UserAgent      string     `json:"user_agent"`
+	IP             string     `json:"ip"`
+	CreatedAt      *time.Time `json:"created_at"`
+	LastUsedAt     *time.Time `json:"last_used_at"`
+	ExpiresAt      *time.Time `json:"expires_at"`
+	RevokedAt      *time.Time `json:"-"`
+	// Current marks the session of the token that made the request.
+	Current bool `json:"current"`
+}
+
+// ClientInfo describes the client a login comes from.
+type ClientInfo struct {
+	UserAgent string
+	IP        string
+}
@@ -8,21 +8,40 @@ import (
 
 type RegisterUser struct {
 	Name     string `json:"name" binding:"required"`
-	Email    string `json:"email" binding:"required"`
+	Email    string `json:"email" binding:"required,email"`
+	Username string `json:"username"`
 	Password string `json:"password" binding:"required"`
+	// DeviceName labels the session the registration logs in.
+	DeviceName string `json:"device_name" binding:"max=100"`
 }
 
 type GetUserDetailRequest struct {
-	UserId uuid.UUID `json:"user_id"`
-	Name   string    `json:"name"`
-	Email  string    `json:"email"`
+	UserId   uuid.UUID `json:"user_id"`
+	Name     string    `json:"name"`
+	Email    string    `json:"email"`
+	Username string    `json:"username"`
 }
 
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
 type User struct {
 	Id        uuid.UUID  `json:"id"`
 	Name      string     `json:"name"`
 	Email     string     `json:"email"`
+	Username  string     `json:"username,omitempty"`
 	Password  string     `json:"password"`
+	Role      string     `json:"role"`
 	CreatedAt *time.Time `json:"created_at"`
 	UpdatedAt *time.Time `json:"updated_at"`
 }
+
+type UsernameAvailability struct {
+	Username  string `json:"username"`
+	Available bool   `json:"available"`
+	// Reason is taken, reserved or the validation rule the username
+	// breaks, when it is not available.
+	Reason string `json:"reason,omitempty"`
+}
@@ -0,0 +1,377 @@
+// Package fake is an in-memory ProductService for local development and
+// for exercising the product client without the real service.
+package fake
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Reza1878/goesclearning/user-service/helper/money"
+	"github.com/Reza1878/goesclearning/user-service/proto/product"
+	"github.com/google/uuid"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/proto"
+)
+
+const violationInsufficientStock = "INSUFFICIENT_STOCK"
+
+type storedProduct struct {
+	product   *product.Product
+	createdAt time.Time
+}
+
+// Server keeps products in memory and follows the contract the product
+// usecase relies on: NotFound for unknown ids, FailedPrecondition with
+// INSUFFICIENT_STOCK violations when stock runs out, and idempotent
+// stock reductions.
+type Server struct {
+	product.UnimplementedProductServiceServer
+
+	mu       sync.Mutex
+	products map[string]*storedProduct
+	reduced  map[string]*product.ReduceProductResponse
+}
+
+func NewServer() *Server {
+	return &Server{
+		products: map[string]*storedProduct{},
+		reduced:  map[string]*product.ReduceProductResponse{},
+	}
+}
+
+// Dial serves srv over an in-process bufconn listener and returns a
+// client connection to it. stop closes both.
+func Dial(srv product.ProductServiceServer) (conn *grpc.ClientConn, stop func(), err error) {
+	lis := bufconn.Listen(1024 * 1024)
+
+	server := grpc.NewServer()
+	product.RegisterProductServiceServer(server, srv)
+	go server.Serve(lis)
+
+	conn, err = grpc.NewClient("passthrough:///fake-product",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		server.Stop()
+		return nil, nil, fmt.Errorf("failed to dial fake product service: %w", err)
+	}
+
+	return conn, func() {
+		conn.Close()
+		server.Stop()
+	}, nil
+}
+
+func (s *Server) InsertProduct(_ context.Context, req *product.ProductInsertRequest) (*product.ProductInsertResponse, error) {
+	if strings.TrimSpace(req.GetName()) == "" {
+		return nil, invalidArgument("name", "name is required")
+	}
+
+	price := requestPrice(req.GetPriceMoney(), req.GetPrice())
+	if price.Minor <= 0 {
+		return nil, invalidArgument("price_money", "price must be positive")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := uuid.NewString()
+	s.products[id] = &storedProduct{
+		product: &product.Product{
+			Id:          id,
+			UserId:      req.GetUserId(),
+			Name:        req.GetName(),
+			Description: req.GetDescription(),
+			Qty:         req.GetQty(),
+			Price:       float32(price.Float()),
+			PriceMoney:  &product.Money{MinorUnits: price.Minor, Currency: price.Currency},
+		},
+		createdAt: time.Now(),
+	}
+
+	return &product.ProductInsertResponse{Msg: fmt.Sprintf("product %s created", id)}, nil
+}
+
+func (s *Server) ListProduct(_ context.Context, req *product.ListProductRequest) (*product.ListProductResponse, error) {
+	page, limit := req.GetPage(), req.GetLimit()
+	if page == 0 {
+		page = 1
+	}
+	if limit == 0 {
+		limit = 10
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var matched []*storedProduct
+	for _, stored := range s.products {
+		if matches(stored.product, req) {
+			matched = append(matched, stored)
+		}
+	}
+
+	if err := sortProducts(matched, req.GetSort()); err != nil {
+		return nil, err
+	}
+
+	res := &product.ListProductResponse{
+		Items: []*product.Product{},
+		Meta: &product.Meta{
+			TotalData:   uint32(len(matched)),
+			TotalPage:   uint32((uint64(len(matched)) + uint64(limit) - 1) / uint64(limit)),
+			CurrentPage: page,
+			Limit:       limit,
+		},
+	}
+
+	// in uint64 so a page far past the end cannot wrap back to the start
+	start := (uint64(page) - 1) * uint64(limit)
+	for i := start; i < uint64(len(matched)) && i < start+uint64(limit); i++ {
+		res.Items = append(res.Items, proto.Clone(matched[i].product).(*product.Product))
+	}
+
+	if req.GetIncludeStats() {
+		res.Stats = stats(matched)
+	}
+
+	return res, nil
+}
+
+func matches(p *product.Product, req *product.ListProductRequest) bool {
+	if len(req.GetProductIds()) > 0 && !contains(req.GetProductIds(), p.GetId()) {
+		return false
+	}
+	if req.GetUserId() != "" && p.GetUserId() != req.GetUserId() {
+		return false
+	}
+
+	if q := strings.ToLower(req.GetQ()); q != "" &&
+		!strings.Contains(strings.ToLower(p.GetName()), q) &&
+		!strings.Contains(strings.ToLower(p.GetDescription()), q) {
+		return false
+	}
+
+	price := p.GetPriceMoney()
+	if min, ok := filterPrice(req.MinPriceMoney, req.MinPrice); ok &&
+		(price.GetCurrency() != min.Currency || price.GetMinorUnits() < min.Minor) {
+		return false
+	}
+	if max, ok := filterPrice(req.MaxPriceMoney, req.MaxPrice); ok &&
+		(price.GetCurrency() != max.Currency || price.GetMinorUnits() > max.Minor) {
+		return false
+	}
+
+	return true
+}
+
+func sortProducts(products []*storedProduct, order string) error {
+	desc := strings.HasPrefix(order, "-")
+	field := strings.TrimPrefix(order, "-")
+
+	var less func(a, b *storedProduct) bool
+	switch field {
+	case "", "created_at":
+		less = func(a, b *storedProduct) bool { return a.createdAt.Before(b.createdAt) }
+	case "name":
+		less = func(a, b *storedProduct) bool { return a.product.GetName() < b.product.GetName() }
+	case "price":
+		less = func(a, b *storedProduct) bool {
+			return a.product.GetPriceMoney().GetMinorUnits() < b.product.GetPriceMoney().GetMinorUnits()
+		}
+	default:
+		return invalidArgument("sort", fmt.Sprintf("unknown sort %q", order))
+	}
+
+	sort.SliceStable(products, func(i, j int) bool {
+		if desc {
+			return less(products[j], products[i])
+		}
+		return less(products[i], products[j])
+	})
+	return nil
+}
+
+func stats(products []*storedProduct) *product.ProductStats {
+	res := &product.ProductStats{Count: uint32(len(products))}
+
+	var value int64
+	currency := ""
+	mixed := false
+	for _, stored := range products {
+		p := stored.product
+		res.TotalQty += uint64(p.GetQty())
+		value += p.GetPriceMoney().GetMinorUnits() * int64(p.GetQty())
+
+		if currency == "" {
+			currency = p.GetPriceMoney().GetCurrency()
+		} else if currency != p.GetPriceMoney().GetCurrency() {
+			mixed = true
+		}
+	}
+
+	if currency != "" && !mixed {
+		total := money.Money{Minor: value, Currency: currency}
+		res.InventoryValue = total.Float()
+		res.InventoryValueMoney = &product.Money{MinorUnits: value, Currency: currency}
+	}
+	return res
+}
+
+func (s *Server) ReduceProductQty(_ context.Context, req *product.ReduceProductRequest) (*product.ReduceProductResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := req.GetUserId() + ":" + req.GetIdempotencyKey()
+	if req.GetIdempotencyKey() != "" {
+		if res, ok := s.reduced[key]; ok {
+			return res, nil
+		}
+	}
+
+	failure := &errdetails.PreconditionFailure{}
+	for _, item := range req.GetItems() {
+		stored, ok := s.products[item.GetProductId()]
+		if !ok {
+			return nil, status.Errorf(codes.NotFound, "product %s not found", item.GetProductId())
+		}
+		if stored.product.GetQty() < item.GetQty() {
+			failure.Violations = append(failure.Violations, &errdetails.PreconditionFailure_Violation{
+				Type:        violationInsufficientStock,
+				Subject:     item.GetProductId(),
+				Description: fmt.Sprintf("requested %d, %d in stock", item.GetQty(), stored.product.GetQty()),
+			})
+		}
+	}
+
+	if len(failure.Violations) > 0 {
+		st, err := status.New(codes.FailedPrecondition, "insufficient stock").WithDetails(failure)
+		if err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+		return nil, st.Err()
+	}
+
+	for _, item := range req.GetItems() {
+		s.products[item.GetProductId()].product.Qty -= item.GetQty()
+	}
+
+	res := &product.ReduceProductResponse{Message: fmt.Sprintf("reduced stock of %d products", len(req.GetItems()))}
+	if req.GetIdempotencyKey() != "" {
+		s.reduced[key] = res
+	}
+	return res, nil
+}
+
+func (s *Server) GetProduct(_ context.Context, req *product.GetProductRequest) (*product.GetProductResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.products[req.GetId()]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "product %s not found", req.GetId())
+	}
+
+	return &product.GetProductResponse{Product: proto.Clone(stored.product).(*product.Product)}, nil
+}
+
+func (s *Server) UpdateProduct(_ context.Context, req *product.UpdateProductRequest) (*product.UpdateProductResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.products[req.GetId()]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "product %s not found", req.GetId())
+	}
+	p := stored.product
+
+	if req.Name != nil {
+		p.Name = req.GetName()
+	}
+	if req.Description != nil {
+		p.Description = req.GetDescription()
+	}
+	if req.Qty != nil {
+		p.Qty = req.GetQty()
+	}
+	if req.PriceMoney != nil || req.Price != nil {
+		price := requestPrice(req.GetPriceMoney(), req.GetPrice())
+		if price.Minor <= 0 {
+			return nil, invalidArgument("price_money", "price must be positive")
+		}
+		p.Price = float32(price.Float())
+		p.PriceMoney = &product.Money{MinorUnits: price.Minor, Currency: price.Currency}
+	}
+
+	return &product.UpdateProductResponse{Product: proto.Clone(p).(*product.Product)}, nil
+}
+
+func (s *Server) DeleteProduct(_ context.Context, req *product.DeleteProductRequest) (*product.DeleteProductResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.products[req.GetId()]; !ok {
+		return nil, status.Errorf(codes.NotFound, "product %s not found", req.GetId())
+	}
+	delete(s.products, req.GetId())
+
+	return &product.DeleteProductResponse{Msg: fmt.Sprintf("product %s deleted", req.GetId())}, nil
+}
+
+// requestPrice prefers the money field and falls back to the deprecated
+// float, like the real service during the migration.
+func requestPrice(m *product.Money, f float32) money.Money {
+	if m != nil {
+		return money.Money{Minor: m.GetMinorUnits(), Currency: m.GetCurrency()}
+	}
+
+	converted, err := money.FromFloat(float64(f), money.DefaultCurrency)
+	if err != nil {
+		return money.Money{Currency: money.DefaultCurrency}
+	}
+	return converted
+}
+
+func filterPrice(m *product.Money, f *float32) (money.Money, bool) {
+	if m == nil && f == nil {
+		return money.Money{}, false
+	}
+
+	var legacy float32
+	if f != nil {
+		legacy = *f
+	}
+	return requestPrice(m, legacy), true
+}
+
+func invalidArgument(field, msg string) error {
+	st, err := status.New(codes.InvalidArgument, msg).WithDetails(&errdetails.BadRequest{
+		FieldViolations: []*errdetails.BadRequest_FieldViolation{{Field: field, Description: msg}},
+	})
+	if err != nil {
+		return status.Error(codes.InvalidArgument, msg)
+	}
+	return st.Err()
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
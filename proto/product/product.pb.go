@@ -21,20 +21,80 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+// Money is an exact amount in the minor unit of its currency, e.g.
+// minor_units 1999 with currency "USD" is 19.99 USD.
+type Money struct {
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	MinorUnits int64                  `protobuf:"varint,1,opt,name=minor_units,json=minorUnits,proto3" json:"minor_units,omitempty"`
+	// currency is an ISO 4217 code.
+	Currency      string `protobuf:"bytes,2,opt,name=currency,proto3" json:"currency,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Money) Reset() {
+	*x = Money{}
+	mi := &file_proto_product_product_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Money) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Money) ProtoMessage() {}
+
+func (x *Money) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_product_product_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Money.ProtoReflect.Descriptor instead.
+func (*Money) Descriptor() ([]byte, []int) {
+	return file_proto_product_product_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Money) GetMinorUnits() int64 {
+	if x != nil {
+		return x.MinorUnits
+	}
+	return 0
+}
+
+func (x *Money) GetCurrency() string {
+	if x != nil {
+		return x.Currency
+	}
+	return ""
+}
+
 type ProductInsertRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	UserId        string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
-	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
-	Price         float32                `protobuf:"fixed32,3,opt,name=price,proto3" json:"price,omitempty"`
-	Description   string                 `protobuf:"bytes,4,opt,name=description,proto3" json:"description,omitempty"`
-	Qty           uint32                 `protobuf:"varint,5,opt,name=qty,proto3" json:"qty,omitempty"`
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	UserId string                 `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Name   string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	// price is kept for product services that predate price_money and
+	// will be removed in the next release.
+	//
+	// Deprecated: Marked as deprecated in proto/product/product.proto.
+	Price         float32 `protobuf:"fixed32,3,opt,name=price,proto3" json:"price,omitempty"`
+	Description   string  `protobuf:"bytes,4,opt,name=description,proto3" json:"description,omitempty"`
+	Qty           uint32  `protobuf:"varint,5,opt,name=qty,proto3" json:"qty,omitempty"`
+	PriceMoney    *Money  `protobuf:"bytes,6,opt,name=price_money,json=priceMoney,proto3" json:"price_money,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *ProductInsertRequest) Reset() {
 	*x = ProductInsertRequest{}
-	mi := &file_proto_product_product_proto_msgTypes[0]
+	mi := &file_proto_product_product_proto_msgTypes[1]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -46,7 +106,7 @@ func (x *ProductInsertRequest) String() string {
 func (*ProductInsertRequest) ProtoMessage() {}
 
 func (x *ProductInsertRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_product_product_proto_msgTypes[0]
+	mi := &file_proto_product_product_proto_msgTypes[1]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -59,7 +119,7 @@ func (x *ProductInsertRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ProductInsertRequest.ProtoReflect.Descriptor instead.
 func (*ProductInsertRequest) Descriptor() ([]byte, []int) {
-	return file_proto_product_product_proto_rawDescGZIP(), []int{0}
+	return file_proto_product_product_proto_rawDescGZIP(), []int{1}
 }
 
 func (x *ProductInsertRequest) GetUserId() string {
@@ -76,6 +136,7 @@ func (x *ProductInsertRequest) GetName() string {
 	return ""
 }
 
+// Deprecated: Marked as deprecated in proto/product/product.proto.
 func (x *ProductInsertRequest) GetPrice() float32 {
 	if x != nil {
 		return x.Price
@@ -97,6 +158,13 @@ func (x *ProductInsertRequest) GetQty() uint32 {
 	return 0
 }
 
+func (x *ProductInsertRequest) GetPriceMoney() *Money {
+	if x != nil {
+		return x.PriceMoney
+	}
+	return nil
+}
+
 type ProductInsertResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Msg           string                 `protobuf:"bytes,1,opt,name=msg,proto3" json:"msg,omitempty"`
@@ -106,7 +174,7 @@ type ProductInsertResponse struct {
 
 func (x *ProductInsertResponse) Reset() {
 	*x = ProductInsertResponse{}
-	mi := &file_proto_product_product_proto_msgTypes[1]
+	mi := &file_proto_product_product_proto_msgTypes[2]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -118,7 +186,7 @@ func (x *ProductInsertResponse) String() string {
 func (*ProductInsertResponse) ProtoMessage() {}
 
 func (x *ProductInsertResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_product_product_proto_msgTypes[1]
+	mi := &file_proto_product_product_proto_msgTypes[2]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -131,7 +199,7 @@ func (x *ProductInsertResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ProductInsertResponse.ProtoReflect.Descriptor instead.
 func (*ProductInsertResponse) Descriptor() ([]byte, []int) {
-	return file_proto_product_product_proto_rawDescGZIP(), []int{1}
+	return file_proto_product_product_proto_rawDescGZIP(), []int{2}
 }
 
 func (x *ProductInsertResponse) GetMsg() string {
@@ -142,20 +210,22 @@ func (x *ProductInsertResponse) GetMsg() string {
 }
 
 type Product struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
-	UserId        string                 `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
-	Name          string                 `protobuf:"bytes,3,opt,name=name,proto3" json:"name,omitempty"`
-	Price         float32                `protobuf:"fixed32,4,opt,name=price,proto3" json:"price,omitempty"`
-	Description   string                 `protobuf:"bytes,5,opt,name=description,proto3" json:"description,omitempty"`
-	Qty           uint32                 `protobuf:"varint,6,opt,name=qty,proto3" json:"qty,omitempty"`
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Id     string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	UserId string                 `protobuf:"bytes,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Name   string                 `protobuf:"bytes,3,opt,name=name,proto3" json:"name,omitempty"`
+	// Deprecated: Marked as deprecated in proto/product/product.proto.
+	Price         float32 `protobuf:"fixed32,4,opt,name=price,proto3" json:"price,omitempty"`
+	Description   string  `protobuf:"bytes,5,opt,name=description,proto3" json:"description,omitempty"`
+	Qty           uint32  `protobuf:"varint,6,opt,name=qty,proto3" json:"qty,omitempty"`
+	PriceMoney    *Money  `protobuf:"bytes,7,opt,name=price_money,json=priceMoney,proto3" json:"price_money,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *Product) Reset() {
 	*x = Product{}
-	mi := &file_proto_product_product_proto_msgTypes[2]
+	mi := &file_proto_product_product_proto_msgTypes[3]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -167,7 +237,7 @@ func (x *Product) String() string {
 func (*Product) ProtoMessage() {}
 
 func (x *Product) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_product_product_proto_msgTypes[2]
+	mi := &file_proto_product_product_proto_msgTypes[3]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -180,7 +250,7 @@ func (x *Product) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Product.ProtoReflect.Descriptor instead.
 func (*Product) Descriptor() ([]byte, []int) {
-	return file_proto_product_product_proto_rawDescGZIP(), []int{2}
+	return file_proto_product_product_proto_rawDescGZIP(), []int{3}
 }
 
 func (x *Product) GetId() string {
@@ -204,6 +274,7 @@ func (x *Product) GetName() string {
 	return ""
 }
 
+// Deprecated: Marked as deprecated in proto/product/product.proto.
 func (x *Product) GetPrice() float32 {
 	if x != nil {
 		return x.Price
@@ -225,18 +296,41 @@ func (x *Product) GetQty() uint32 {
 	return 0
 }
 
+func (x *Product) GetPriceMoney() *Money {
+	if x != nil {
+		return x.PriceMoney
+	}
+	return nil
+}
+
 type ListProductRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Page          uint32                 `protobuf:"varint,1,opt,name=page,proto3" json:"page,omitempty"`
-	Limit         uint32                 `protobuf:"varint,2,opt,name=limit,proto3" json:"limit,omitempty"`
-	ProductIds    []string               `protobuf:"bytes,3,rep,name=product_ids,json=productIds,proto3" json:"product_ids,omitempty"`
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	Page       uint32                 `protobuf:"varint,1,opt,name=page,proto3" json:"page,omitempty"`
+	Limit      uint32                 `protobuf:"varint,2,opt,name=limit,proto3" json:"limit,omitempty"`
+	ProductIds []string               `protobuf:"bytes,3,rep,name=product_ids,json=productIds,proto3" json:"product_ids,omitempty"`
+	// q is a full-text search over name and description.
+	Q string `protobuf:"bytes,4,opt,name=q,proto3" json:"q,omitempty"`
+	// Deprecated: Marked as deprecated in proto/product/product.proto.
+	MinPrice *float32 `protobuf:"fixed32,5,opt,name=min_price,json=minPrice,proto3,oneof" json:"min_price,omitempty"`
+	// Deprecated: Marked as deprecated in proto/product/product.proto.
+	MaxPrice *float32 `protobuf:"fixed32,6,opt,name=max_price,json=maxPrice,proto3,oneof" json:"max_price,omitempty"`
+	// user_id restricts the list to products owned by that user.
+	UserId string `protobuf:"bytes,7,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	// sort is one of price, -price, name, -name, created_at, -created_at;
+	// a leading dash sorts descending.
+	Sort string `protobuf:"bytes,8,opt,name=sort,proto3" json:"sort,omitempty"`
+	// include_stats asks for aggregates over every matching product,
+	// not just the returned page.
+	IncludeStats  bool   `protobuf:"varint,9,opt,name=include_stats,json=includeStats,proto3" json:"include_stats,omitempty"`
+	MinPriceMoney *Money `protobuf:"bytes,10,opt,name=min_price_money,json=minPriceMoney,proto3" json:"min_price_money,omitempty"`
+	MaxPriceMoney *Money `protobuf:"bytes,11,opt,name=max_price_money,json=maxPriceMoney,proto3" json:"max_price_money,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *ListProductRequest) Reset() {
 	*x = ListProductRequest{}
-	mi := &file_proto_product_product_proto_msgTypes[3]
+	mi := &file_proto_product_product_proto_msgTypes[4]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -248,7 +342,7 @@ func (x *ListProductRequest) String() string {
 func (*ListProductRequest) ProtoMessage() {}
 
 func (x *ListProductRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_product_product_proto_msgTypes[3]
+	mi := &file_proto_product_product_proto_msgTypes[4]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -261,7 +355,7 @@ func (x *ListProductRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ListProductRequest.ProtoReflect.Descriptor instead.
 func (*ListProductRequest) Descriptor() ([]byte, []int) {
-	return file_proto_product_product_proto_rawDescGZIP(), []int{3}
+	return file_proto_product_product_proto_rawDescGZIP(), []int{4}
 }
 
 func (x *ListProductRequest) GetPage() uint32 {
@@ -285,6 +379,64 @@ func (x *ListProductRequest) GetProductIds() []string {
 	return nil
 }
 
+func (x *ListProductRequest) GetQ() string {
+	if x != nil {
+		return x.Q
+	}
+	return ""
+}
+
+// Deprecated: Marked as deprecated in proto/product/product.proto.
+func (x *ListProductRequest) GetMinPrice() float32 {
+	if x != nil && x.MinPrice != nil {
+		return *x.MinPrice
+	}
+	return 0
+}
+
+// Deprecated: Marked as deprecated in proto/product/product.proto.
+func (x *ListProductRequest) GetMaxPrice() float32 {
+	if x != nil && x.MaxPrice != nil {
+		return *x.MaxPrice
+	}
+	return 0
+}
+
+func (x *ListProductRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
+func (x *ListProductRequest) GetSort() string {
+	if x != nil {
+		return x.Sort
+	}
+	return ""
+}
+
+func (x *ListProductRequest) GetIncludeStats() bool {
+	if x != nil {
+		return x.IncludeStats
+	}
+	return false
+}
+
+func (x *ListProductRequest) GetMinPriceMoney() *Money {
+	if x != nil {
+		return x.MinPriceMoney
+	}
+	return nil
+}
+
+func (x *ListProductRequest) GetMaxPriceMoney() *Money {
+	if x != nil {
+		return x.MaxPriceMoney
+	}
+	return nil
+}
+
 type Meta struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	TotalData     uint32                 `protobuf:"varint,1,opt,name=total_data,json=totalData,proto3" json:"total_data,omitempty"`
@@ -297,7 +449,7 @@ type Meta struct {
 
 func (x *Meta) Reset() {
 	*x = Meta{}
-	mi := &file_proto_product_product_proto_msgTypes[4]
+	mi := &file_proto_product_product_proto_msgTypes[5]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -309,7 +461,7 @@ func (x *Meta) String() string {
 func (*Meta) ProtoMessage() {}
 
 func (x *Meta) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_product_product_proto_msgTypes[4]
+	mi := &file_proto_product_product_proto_msgTypes[5]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -322,7 +474,7 @@ func (x *Meta) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Meta.ProtoReflect.Descriptor instead.
 func (*Meta) Descriptor() ([]byte, []int) {
-	return file_proto_product_product_proto_rawDescGZIP(), []int{4}
+	return file_proto_product_product_proto_rawDescGZIP(), []int{5}
 }
 
 func (x *Meta) GetTotalData() uint32 {
@@ -353,17 +505,92 @@ func (x *Meta) GetLimit() uint32 {
 	return 0
 }
 
+type ProductStats struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Count    uint32                 `protobuf:"varint,1,opt,name=count,proto3" json:"count,omitempty"`
+	TotalQty uint64                 `protobuf:"varint,2,opt,name=total_qty,json=totalQty,proto3" json:"total_qty,omitempty"`
+	// inventory_value is the sum of price * qty.
+	//
+	// Deprecated: Marked as deprecated in proto/product/product.proto.
+	InventoryValue float64 `protobuf:"fixed64,3,opt,name=inventory_value,json=inventoryValue,proto3" json:"inventory_value,omitempty"`
+	// inventory_value_money is only set when every matching product is
+	// priced in the same currency.
+	InventoryValueMoney *Money `protobuf:"bytes,4,opt,name=inventory_value_money,json=inventoryValueMoney,proto3" json:"inventory_value_money,omitempty"`
+	unknownFields       protoimpl.UnknownFields
+	sizeCache           protoimpl.SizeCache
+}
+
+func (x *ProductStats) Reset() {
+	*x = ProductStats{}
+	mi := &file_proto_product_product_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ProductStats) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProductStats) ProtoMessage() {}
+
+func (x *ProductStats) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_product_product_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProductStats.ProtoReflect.Descriptor instead.
+func (*ProductStats) Descriptor() ([]byte, []int) {
+	return file_proto_product_product_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *ProductStats) GetCount() uint32 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+func (x *ProductStats) GetTotalQty() uint64 {
+	if x != nil {
+		return x.TotalQty
+	}
+	return 0
+}
+
+// Deprecated: Marked as deprecated in proto/product/product.proto.
+func (x *ProductStats) GetInventoryValue() float64 {
+	if x != nil {
+		return x.InventoryValue
+	}
+	return 0
+}
+
+func (x *ProductStats) GetInventoryValueMoney() *Money {
+	if x != nil {
+		return x.InventoryValueMoney
+	}
+	return nil
+}
+
 type ListProductResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Items         []*Product             `protobuf:"bytes,1,rep,name=items,proto3" json:"items,omitempty"`
 	Meta          *Meta                  `protobuf:"bytes,2,opt,name=meta,proto3" json:"meta,omitempty"`
+	Stats         *ProductStats          `protobuf:"bytes,3,opt,name=stats,proto3" json:"stats,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *ListProductResponse) Reset() {
 	*x = ListProductResponse{}
-	mi := &file_proto_product_product_proto_msgTypes[5]
+	mi := &file_proto_product_product_proto_msgTypes[7]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -375,7 +602,7 @@ func (x *ListProductResponse) String() string {
 func (*ListProductResponse) ProtoMessage() {}
 
 func (x *ListProductResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_product_product_proto_msgTypes[5]
+	mi := &file_proto_product_product_proto_msgTypes[7]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -388,7 +615,7 @@ func (x *ListProductResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ListProductResponse.ProtoReflect.Descriptor instead.
 func (*ListProductResponse) Descriptor() ([]byte, []int) {
-	return file_proto_product_product_proto_rawDescGZIP(), []int{5}
+	return file_proto_product_product_proto_rawDescGZIP(), []int{7}
 }
 
 func (x *ListProductResponse) GetItems() []*Product {
@@ -405,6 +632,13 @@ func (x *ListProductResponse) GetMeta() *Meta {
 	return nil
 }
 
+func (x *ListProductResponse) GetStats() *ProductStats {
+	if x != nil {
+		return x.Stats
+	}
+	return nil
+}
+
 type ReduceProductItem struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	ProductId     string                 `protobuf:"bytes,1,opt,name=product_id,json=productId,proto3" json:"product_id,omitempty"`
@@ -415,7 +649,7 @@ type ReduceProductItem struct {
 
 func (x *ReduceProductItem) Reset() {
 	*x = ReduceProductItem{}
-	mi := &file_proto_product_product_proto_msgTypes[6]
+	mi := &file_proto_product_product_proto_msgTypes[8]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -427,7 +661,7 @@ func (x *ReduceProductItem) String() string {
 func (*ReduceProductItem) ProtoMessage() {}
 
 func (x *ReduceProductItem) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_product_product_proto_msgTypes[6]
+	mi := &file_proto_product_product_proto_msgTypes[8]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -440,7 +674,7 @@ func (x *ReduceProductItem) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ReduceProductItem.ProtoReflect.Descriptor instead.
 func (*ReduceProductItem) Descriptor() ([]byte, []int) {
-	return file_proto_product_product_proto_rawDescGZIP(), []int{6}
+	return file_proto_product_product_proto_rawDescGZIP(), []int{8}
 }
 
 func (x *ReduceProductItem) GetProductId() string {
@@ -458,15 +692,19 @@ func (x *ReduceProductItem) GetQty() uint32 {
 }
 
 type ReduceProductRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Items         []*ReduceProductItem   `protobuf:"bytes,1,rep,name=items,proto3" json:"items,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Items []*ReduceProductItem   `protobuf:"bytes,1,rep,name=items,proto3" json:"items,omitempty"`
+	// idempotency_key lets the product service drop a retried request
+	// instead of decrementing stock twice.
+	IdempotencyKey string `protobuf:"bytes,2,opt,name=idempotency_key,json=idempotencyKey,proto3" json:"idempotency_key,omitempty"`
+	UserId         string `protobuf:"bytes,3,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
 }
 
 func (x *ReduceProductRequest) Reset() {
 	*x = ReduceProductRequest{}
-	mi := &file_proto_product_product_proto_msgTypes[7]
+	mi := &file_proto_product_product_proto_msgTypes[9]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -478,7 +716,7 @@ func (x *ReduceProductRequest) String() string {
 func (*ReduceProductRequest) ProtoMessage() {}
 
 func (x *ReduceProductRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_product_product_proto_msgTypes[7]
+	mi := &file_proto_product_product_proto_msgTypes[9]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -491,7 +729,7 @@ func (x *ReduceProductRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ReduceProductRequest.ProtoReflect.Descriptor instead.
 func (*ReduceProductRequest) Descriptor() ([]byte, []int) {
-	return file_proto_product_product_proto_rawDescGZIP(), []int{7}
+	return file_proto_product_product_proto_rawDescGZIP(), []int{9}
 }
 
 func (x *ReduceProductRequest) GetItems() []*ReduceProductItem {
@@ -501,6 +739,20 @@ func (x *ReduceProductRequest) GetItems() []*ReduceProductItem {
 	return nil
 }
 
+func (x *ReduceProductRequest) GetIdempotencyKey() string {
+	if x != nil {
+		return x.IdempotencyKey
+	}
+	return ""
+}
+
+func (x *ReduceProductRequest) GetUserId() string {
+	if x != nil {
+		return x.UserId
+	}
+	return ""
+}
+
 type ReduceProductResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Message       string                 `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
@@ -510,7 +762,7 @@ type ReduceProductResponse struct {
 
 func (x *ReduceProductResponse) Reset() {
 	*x = ReduceProductResponse{}
-	mi := &file_proto_product_product_proto_msgTypes[8]
+	mi := &file_proto_product_product_proto_msgTypes[10]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -522,7 +774,7 @@ func (x *ReduceProductResponse) String() string {
 func (*ReduceProductResponse) ProtoMessage() {}
 
 func (x *ReduceProductResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_product_product_proto_msgTypes[8]
+	mi := &file_proto_product_product_proto_msgTypes[10]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -535,7 +787,7 @@ func (x *ReduceProductResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ReduceProductResponse.ProtoReflect.Descriptor instead.
 func (*ReduceProductResponse) Descriptor() ([]byte, []int) {
-	return file_proto_product_product_proto_rawDescGZIP(), []int{8}
+	return file_proto_product_product_proto_rawDescGZIP(), []int{10}
 }
 
 func (x *ReduceProductResponse) GetMessage() string {
@@ -545,53 +797,414 @@ func (x *ReduceProductResponse) GetMessage() string {
 	return ""
 }
 
+type GetProductRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetProductRequest) Reset() {
+	*x = GetProductRequest{}
+	mi := &file_proto_product_product_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetProductRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetProductRequest) ProtoMessage() {}
+
+func (x *GetProductRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_product_product_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetProductRequest.ProtoReflect.Descriptor instead.
+func (*GetProductRequest) Descriptor() ([]byte, []int) {
+	return file_proto_product_product_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *GetProductRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type GetProductResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Product       *Product               `protobuf:"bytes,1,opt,name=product,proto3" json:"product,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetProductResponse) Reset() {
+	*x = GetProductResponse{}
+	mi := &file_proto_product_product_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetProductResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetProductResponse) ProtoMessage() {}
+
+func (x *GetProductResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_product_product_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetProductResponse.ProtoReflect.Descriptor instead.
+func (*GetProductResponse) Descriptor() ([]byte, []int) {
+	return file_proto_product_product_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *GetProductResponse) GetProduct() *Product {
+	if x != nil {
+		return x.Product
+	}
+	return nil
+}
+
+type UpdateProductRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Id    string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name  *string                `protobuf:"bytes,2,opt,name=name,proto3,oneof" json:"name,omitempty"`
+	// Deprecated: Marked as deprecated in proto/product/product.proto.
+	Price         *float32 `protobuf:"fixed32,3,opt,name=price,proto3,oneof" json:"price,omitempty"`
+	Description   *string  `protobuf:"bytes,4,opt,name=description,proto3,oneof" json:"description,omitempty"`
+	Qty           *uint32  `protobuf:"varint,5,opt,name=qty,proto3,oneof" json:"qty,omitempty"`
+	PriceMoney    *Money   `protobuf:"bytes,6,opt,name=price_money,json=priceMoney,proto3" json:"price_money,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateProductRequest) Reset() {
+	*x = UpdateProductRequest{}
+	mi := &file_proto_product_product_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateProductRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateProductRequest) ProtoMessage() {}
+
+func (x *UpdateProductRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_product_product_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateProductRequest.ProtoReflect.Descriptor instead.
+func (*UpdateProductRequest) Descriptor() ([]byte, []int) {
+	return file_proto_product_product_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *UpdateProductRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *UpdateProductRequest) GetName() string {
+	if x != nil && x.Name != nil {
+		return *x.Name
+	}
+	return ""
+}
+
+// Deprecated: Marked as deprecated in proto/product/product.proto.
+func (x *UpdateProductRequest) GetPrice() float32 {
+	if x != nil && x.Price != nil {
+		return *x.Price
+	}
+	return 0
+}
+
+func (x *UpdateProductRequest) GetDescription() string {
+	if x != nil && x.Description != nil {
+		return *x.Description
+	}
+	return ""
+}
+
+func (x *UpdateProductRequest) GetQty() uint32 {
+	if x != nil && x.Qty != nil {
+		return *x.Qty
+	}
+	return 0
+}
+
+func (x *UpdateProductRequest) GetPriceMoney() *Money {
+	if x != nil {
+		return x.PriceMoney
+	}
+	return nil
+}
+
+type UpdateProductResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Product       *Product               `protobuf:"bytes,1,opt,name=product,proto3" json:"product,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateProductResponse) Reset() {
+	*x = UpdateProductResponse{}
+	mi := &file_proto_product_product_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateProductResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateProductResponse) ProtoMessage() {}
+
+func (x *UpdateProductResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_product_product_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateProductResponse.ProtoReflect.Descriptor instead.
+func (*UpdateProductResponse) Descriptor() ([]byte, []int) {
+	return file_proto_product_product_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *UpdateProductResponse) GetProduct() *Product {
+	if x != nil {
+		return x.Product
+	}
+	return nil
+}
+
+type DeleteProductRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteProductRequest) Reset() {
+	*x = DeleteProductRequest{}
+	mi := &file_proto_product_product_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteProductRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteProductRequest) ProtoMessage() {}
+
+func (x *DeleteProductRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_product_product_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteProductRequest.ProtoReflect.Descriptor instead.
+func (*DeleteProductRequest) Descriptor() ([]byte, []int) {
+	return file_proto_product_product_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *DeleteProductRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type DeleteProductResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Msg           string                 `protobuf:"bytes,1,opt,name=msg,proto3" json:"msg,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteProductResponse) Reset() {
+	*x = DeleteProductResponse{}
+	mi := &file_proto_product_product_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteProductResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteProductResponse) ProtoMessage() {}
+
+func (x *DeleteProductResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_product_product_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteProductResponse.ProtoReflect.Descriptor instead.
+func (*DeleteProductResponse) Descriptor() ([]byte, []int) {
+	return file_proto_product_product_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *DeleteProductResponse) GetMsg() string {
+	if x != nil {
+		return x.Msg
+	}
+	return ""
+}
+
 var File_proto_product_product_proto protoreflect.FileDescriptor
 
 const file_proto_product_product_proto_rawDesc = "" +
 	"\n" +
-	"\x1bproto/product/product.proto\x12\x05proto\"\x8d\x01\n" +
+	"\x1bproto/product/product.proto\x12\x05proto\"D\n" +
+	"\x05Money\x12\x1f\n" +
+	"\vminor_units\x18\x01 \x01(\x03R\n" +
+	"minorUnits\x12\x1a\n" +
+	"\bcurrency\x18\x02 \x01(\tR\bcurrency\"\xc0\x01\n" +
 	"\x14ProductInsertRequest\x12\x17\n" +
 	"\auser_id\x18\x01 \x01(\tR\x06userId\x12\x12\n" +
-	"\x04name\x18\x02 \x01(\tR\x04name\x12\x14\n" +
-	"\x05price\x18\x03 \x01(\x02R\x05price\x12 \n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x18\n" +
+	"\x05price\x18\x03 \x01(\x02B\x02\x18\x01R\x05price\x12 \n" +
 	"\vdescription\x18\x04 \x01(\tR\vdescription\x12\x10\n" +
-	"\x03qty\x18\x05 \x01(\rR\x03qty\")\n" +
+	"\x03qty\x18\x05 \x01(\rR\x03qty\x12-\n" +
+	"\vprice_money\x18\x06 \x01(\v2\f.proto.MoneyR\n" +
+	"priceMoney\")\n" +
 	"\x15ProductInsertResponse\x12\x10\n" +
-	"\x03msg\x18\x01 \x01(\tR\x03msg\"\x90\x01\n" +
+	"\x03msg\x18\x01 \x01(\tR\x03msg\"\xc3\x01\n" +
 	"\aProduct\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\tR\x02id\x12\x17\n" +
 	"\auser_id\x18\x02 \x01(\tR\x06userId\x12\x12\n" +
-	"\x04name\x18\x03 \x01(\tR\x04name\x12\x14\n" +
-	"\x05price\x18\x04 \x01(\x02R\x05price\x12 \n" +
+	"\x04name\x18\x03 \x01(\tR\x04name\x12\x18\n" +
+	"\x05price\x18\x04 \x01(\x02B\x02\x18\x01R\x05price\x12 \n" +
 	"\vdescription\x18\x05 \x01(\tR\vdescription\x12\x10\n" +
-	"\x03qty\x18\x06 \x01(\rR\x03qty\"_\n" +
+	"\x03qty\x18\x06 \x01(\rR\x03qty\x12-\n" +
+	"\vprice_money\x18\a \x01(\v2\f.proto.MoneyR\n" +
+	"priceMoney\"\x93\x03\n" +
 	"\x12ListProductRequest\x12\x12\n" +
 	"\x04page\x18\x01 \x01(\rR\x04page\x12\x14\n" +
 	"\x05limit\x18\x02 \x01(\rR\x05limit\x12\x1f\n" +
 	"\vproduct_ids\x18\x03 \x03(\tR\n" +
-	"productIds\"}\n" +
+	"productIds\x12\f\n" +
+	"\x01q\x18\x04 \x01(\tR\x01q\x12$\n" +
+	"\tmin_price\x18\x05 \x01(\x02B\x02\x18\x01H\x00R\bminPrice\x88\x01\x01\x12$\n" +
+	"\tmax_price\x18\x06 \x01(\x02B\x02\x18\x01H\x01R\bmaxPrice\x88\x01\x01\x12\x17\n" +
+	"\auser_id\x18\a \x01(\tR\x06userId\x12\x12\n" +
+	"\x04sort\x18\b \x01(\tR\x04sort\x12#\n" +
+	"\rinclude_stats\x18\t \x01(\bR\fincludeStats\x124\n" +
+	"\x0fmin_price_money\x18\n" +
+	" \x01(\v2\f.proto.MoneyR\rminPriceMoney\x124\n" +
+	"\x0fmax_price_money\x18\v \x01(\v2\f.proto.MoneyR\rmaxPriceMoneyB\f\n" +
+	"\n" +
+	"_min_priceB\f\n" +
+	"\n" +
+	"_max_price\"}\n" +
 	"\x04Meta\x12\x1d\n" +
 	"\n" +
 	"total_data\x18\x01 \x01(\rR\ttotalData\x12\x1d\n" +
 	"\n" +
 	"total_page\x18\x02 \x01(\rR\ttotalPage\x12!\n" +
 	"\fcurrent_page\x18\x03 \x01(\rR\vcurrentPage\x12\x14\n" +
-	"\x05limit\x18\x04 \x01(\rR\x05limit\"\\\n" +
+	"\x05limit\x18\x04 \x01(\rR\x05limit\"\xb0\x01\n" +
+	"\fProductStats\x12\x14\n" +
+	"\x05count\x18\x01 \x01(\rR\x05count\x12\x1b\n" +
+	"\ttotal_qty\x18\x02 \x01(\x04R\btotalQty\x12+\n" +
+	"\x0finventory_value\x18\x03 \x01(\x01B\x02\x18\x01R\x0einventoryValue\x12@\n" +
+	"\x15inventory_value_money\x18\x04 \x01(\v2\f.proto.MoneyR\x13inventoryValueMoney\"\x87\x01\n" +
 	"\x13ListProductResponse\x12$\n" +
 	"\x05items\x18\x01 \x03(\v2\x0e.proto.ProductR\x05items\x12\x1f\n" +
-	"\x04meta\x18\x02 \x01(\v2\v.proto.MetaR\x04meta\"D\n" +
+	"\x04meta\x18\x02 \x01(\v2\v.proto.MetaR\x04meta\x12)\n" +
+	"\x05stats\x18\x03 \x01(\v2\x13.proto.ProductStatsR\x05stats\"D\n" +
 	"\x11ReduceProductItem\x12\x1d\n" +
 	"\n" +
 	"product_id\x18\x01 \x01(\tR\tproductId\x12\x10\n" +
-	"\x03qty\x18\x02 \x01(\rR\x03qty\"F\n" +
+	"\x03qty\x18\x02 \x01(\rR\x03qty\"\x88\x01\n" +
 	"\x14ReduceProductRequest\x12.\n" +
-	"\x05items\x18\x01 \x03(\v2\x18.proto.ReduceProductItemR\x05items\"1\n" +
+	"\x05items\x18\x01 \x03(\v2\x18.proto.ReduceProductItemR\x05items\x12'\n" +
+	"\x0fidempotency_key\x18\x02 \x01(\tR\x0eidempotencyKey\x12\x17\n" +
+	"\auser_id\x18\x03 \x01(\tR\x06userId\"1\n" +
 	"\x15ReduceProductResponse\x12\x18\n" +
-	"\amessage\x18\x01 \x01(\tR\amessage2\xf1\x01\n" +
+	"\amessage\x18\x01 \x01(\tR\amessage\"#\n" +
+	"\x11GetProductRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\">\n" +
+	"\x12GetProductResponse\x12(\n" +
+	"\aproduct\x18\x01 \x01(\v2\x0e.proto.ProductR\aproduct\"\xf6\x01\n" +
+	"\x14UpdateProductRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x17\n" +
+	"\x04name\x18\x02 \x01(\tH\x00R\x04name\x88\x01\x01\x12\x1d\n" +
+	"\x05price\x18\x03 \x01(\x02B\x02\x18\x01H\x01R\x05price\x88\x01\x01\x12%\n" +
+	"\vdescription\x18\x04 \x01(\tH\x02R\vdescription\x88\x01\x01\x12\x15\n" +
+	"\x03qty\x18\x05 \x01(\rH\x03R\x03qty\x88\x01\x01\x12-\n" +
+	"\vprice_money\x18\x06 \x01(\v2\f.proto.MoneyR\n" +
+	"priceMoneyB\a\n" +
+	"\x05_nameB\b\n" +
+	"\x06_priceB\x0e\n" +
+	"\f_descriptionB\x06\n" +
+	"\x04_qty\"A\n" +
+	"\x15UpdateProductResponse\x12(\n" +
+	"\aproduct\x18\x01 \x01(\v2\x0e.proto.ProductR\aproduct\"&\n" +
+	"\x14DeleteProductRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\")\n" +
+	"\x15DeleteProductResponse\x12\x10\n" +
+	"\x03msg\x18\x01 \x01(\tR\x03msg2\xcc\x03\n" +
 	"\x0eProductService\x12J\n" +
 	"\rInsertProduct\x12\x1b.proto.ProductInsertRequest\x1a\x1c.proto.ProductInsertResponse\x12D\n" +
 	"\vListProduct\x12\x19.proto.ListProductRequest\x1a\x1a.proto.ListProductResponse\x12M\n" +
-	"\x10ReduceProductQty\x12\x1b.proto.ReduceProductRequest\x1a\x1c.proto.ReduceProductResponseB\x0fZ\rproto/productb\x06proto3"
+	"\x10ReduceProductQty\x12\x1b.proto.ReduceProductRequest\x1a\x1c.proto.ReduceProductResponse\x12A\n" +
+	"\n" +
+	"GetProduct\x12\x18.proto.GetProductRequest\x1a\x19.proto.GetProductResponse\x12J\n" +
+	"\rUpdateProduct\x12\x1b.proto.UpdateProductRequest\x1a\x1c.proto.UpdateProductResponse\x12J\n" +
+	"\rDeleteProduct\x12\x1b.proto.DeleteProductRequest\x1a\x1c.proto.DeleteProductResponseB\x0fZ\rproto/productb\x06proto3"
 
 var (
 	file_proto_product_product_proto_rawDescOnce sync.Once
@@ -605,33 +1218,56 @@ func file_proto_product_product_proto_rawDescGZIP() []byte {
 	return file_proto_product_product_proto_rawDescData
 }
 
-var file_proto_product_product_proto_msgTypes = make([]protoimpl.MessageInfo, 9)
+var file_proto_product_product_proto_msgTypes = make([]protoimpl.MessageInfo, 17)
 var file_proto_product_product_proto_goTypes = []any{
-	(*ProductInsertRequest)(nil),  // 0: proto.ProductInsertRequest
-	(*ProductInsertResponse)(nil), // 1: proto.ProductInsertResponse
-	(*Product)(nil),               // 2: proto.Product
-	(*ListProductRequest)(nil),    // 3: proto.ListProductRequest
-	(*Meta)(nil),                  // 4: proto.Meta
-	(*ListProductResponse)(nil),   // 5: proto.ListProductResponse
-	(*ReduceProductItem)(nil),     // 6: proto.ReduceProductItem
-	(*ReduceProductRequest)(nil),  // 7: proto.ReduceProductRequest
-	(*ReduceProductResponse)(nil), // 8: proto.ReduceProductResponse
+	(*Money)(nil),                 // 0: proto.Money
+	(*ProductInsertRequest)(nil),  // 1: proto.ProductInsertRequest
+	(*ProductInsertResponse)(nil), // 2: proto.ProductInsertResponse
+	(*Product)(nil),               // 3: proto.Product
+	(*ListProductRequest)(nil),    // 4: proto.ListProductRequest
+	(*Meta)(nil),                  // 5: proto.Meta
+	(*ProductStats)(nil),          // 6: proto.ProductStats
+	(*ListProductResponse)(nil),   // 7: proto.ListProductResponse
+	(*ReduceProductItem)(nil),     // 8: proto.ReduceProductItem
+	(*ReduceProductRequest)(nil),  // 9: proto.ReduceProductRequest
+	(*ReduceProductResponse)(nil), // 10: proto.ReduceProductResponse
+	(*GetProductRequest)(nil),     // 11: proto.GetProductRequest
+	(*GetProductResponse)(nil),    // 12: proto.GetProductResponse
+	(*UpdateProductRequest)(nil),  // 13: proto.UpdateProductRequest
+	(*UpdateProductResponse)(nil), // 14: proto.UpdateProductResponse
+	(*DeleteProductRequest)(nil),  // 15: proto.DeleteProductRequest
+	(*DeleteProductResponse)(nil), // 16: proto.DeleteProductResponse
 }
 var file_proto_product_product_proto_depIdxs = []int32{
-	2, // 0: proto.ListProductResponse.items:type_name -> proto.Product
-	4, // 1: proto.ListProductResponse.meta:type_name -> proto.Meta
-	6, // 2: proto.ReduceProductRequest.items:type_name -> proto.ReduceProductItem
-	0, // 3: proto.ProductService.InsertProduct:input_type -> proto.ProductInsertRequest
-	3, // 4: proto.ProductService.ListProduct:input_type -> proto.ListProductRequest
-	7, // 5: proto.ProductService.ReduceProductQty:input_type -> proto.ReduceProductRequest
-	1, // 6: proto.ProductService.InsertProduct:output_type -> proto.ProductInsertResponse
-	5, // 7: proto.ProductService.ListProduct:output_type -> proto.ListProductResponse
-	8, // 8: proto.ProductService.ReduceProductQty:output_type -> proto.ReduceProductResponse
-	6, // [6:9] is the sub-list for method output_type
-	3, // [3:6] is the sub-list for method input_type
-	3, // [3:3] is the sub-list for extension type_name
-	3, // [3:3] is the sub-list for extension extendee
-	0, // [0:3] is the sub-list for field type_name
+	0,  // 0: proto.ProductInsertRequest.price_money:type_name -> proto.Money
+	0,  // 1: proto.Product.price_money:type_name -> proto.Money
+	0,  // 2: proto.ListProductRequest.min_price_money:type_name -> proto.Money
+	0,  // 3: proto.ListProductRequest.max_price_money:type_name -> proto.Money
+	0,  // 4: proto.ProductStats.inventory_value_money:type_name -> proto.Money
+	3,  // 5: proto.ListProductResponse.items:type_name -> proto.Product
+	5,  // 6: proto.ListProductResponse.meta:type_name -> proto.Meta
+	6,  // 7: proto.ListProductResponse.stats:type_name -> proto.ProductStats
+	8,  // 8: proto.ReduceProductRequest.items:type_name -> proto.ReduceProductItem
+	3,  // 9: proto.GetProductResponse.product:type_name -> proto.Product
+	0,  // 10: proto.UpdateProductRequest.price_money:type_name -> proto.Money
+	3,  // 11: proto.UpdateProductResponse.product:type_name -> proto.Product
+	1,  // 12: proto.ProductService.InsertProduct:input_type -> proto.ProductInsertRequest
+	4,  // 13: proto.ProductService.ListProduct:input_type -> proto.ListProductRequest
+	9,  // 14: proto.ProductService.ReduceProductQty:input_type -> proto.ReduceProductRequest
+	11, // 15: proto.ProductService.GetProduct:input_type -> proto.GetProductRequest
+	13, // 16: proto.ProductService.UpdateProduct:input_type -> proto.UpdateProductRequest
+	15, // 17: proto.ProductService.DeleteProduct:input_type -> proto.DeleteProductRequest
+	2,  // 18: proto.ProductService.InsertProduct:output_type -> proto.ProductInsertResponse
+	7,  // 19: proto.ProductService.ListProduct:output_type -> proto.ListProductResponse
+	10, // 20: proto.ProductService.ReduceProductQty:output_type -> proto.ReduceProductResponse
+	12, // 21: proto.ProductService.GetProduct:output_type -> proto.GetProductResponse
+	14, // 22: proto.ProductService.UpdateProduct:output_type -> proto.UpdateProductResponse
+	16, // 23: proto.ProductService.DeleteProduct:output_type -> proto.DeleteProductResponse
+	18, // [18:24] is the sub-list for method output_type
+	12, // [12:18] is the sub-list for method input_type
+	12, // [12:12] is the sub-list for extension type_name
+	12, // [12:12] is the sub-list for extension extendee
+	0,  // [0:12] is the sub-list for field type_name
 }
 
 func init() { file_proto_product_product_proto_init() }
@@ -639,13 +1275,15 @@ func file_proto_product_product_proto_init() {
 	if File_proto_product_product_proto != nil {
 		return
 	}
+	file_proto_product_product_proto_msgTypes[4].OneofWrappers = []any{}
+	file_proto_product_product_proto_msgTypes[13].OneofWrappers = []any{}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_proto_product_product_proto_rawDesc), len(file_proto_product_product_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   9,
+			NumMessages:   17,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -0,0 +1,152 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Reza1878/goesclearning/user-service/helper/fault"
+	"github.com/Reza1878/goesclearning/user-service/model"
+	"github.com/google/uuid"
+)
+
+type store struct {
+	db *sql.DB
+}
+
+func NewStore(db *sql.DB) *store {
+	return &store{db: db}
+}
+
+type LoginEventRepository interface {
+	InsertLoginEvent(event model.LoginEvent) error
+	// ListLoginEvents returns one page of events, newest first, and the
+	// number of events matching the filter. uuid.Nil lists every user.
+	ListLoginEvents(userId uuid.UUID, query model.LoginEventQuery) ([]model.LoginEvent, int, error)
+	// PruneLoginEvents deletes up to limit events older than before and
+	// reports how many it deleted.
+	PruneLoginEvents(before time.Time, limit int) (int64, error)
+}
+
+func (s *store) InsertLoginEvent(event model.LoginEvent) error {
+	query := `INSERT INTO login_events(user_id, identifier, event, reason, session_id, ip, user_agent)
+		VALUES($1, $2, $3, $4, $5, $6, $7)`
+
+	_, err := s.db.Exec(query,
+		event.UserId,
+		event.Identifier,
+		event.Event,
+		event.Reason,
+		event.SessionId,
+		event.IP,
+		event.UserAgent,
+	)
+	if err != nil {
+		return fault.Custom(
+			http.StatusInternalServerError,
+			fault.ErrInternalServer,
+			fmt.Sprintf("failed to insert %s login event: %v", event.Event, err),
+		)
+	}
+
+	return nil
+}
+
+func (s *store) ListLoginEvents(userId uuid.UUID, query model.LoginEventQuery) ([]model.LoginEvent, int, error) {
+	var args []interface{}
+	var conditions []string
+
+	argPos := 1
+
+	if userId != uuid.Nil {
+		conditions = append(conditions, fmt.Sprintf("user_id = $%d", argPos))
+		args = append(args, userId)
+		argPos++
+	}
+
+	if query.Event != "" {
+		conditions = append(conditions, fmt.Sprintf("event = $%d", argPos))
+		args = append(args, query.Event)
+		argPos++
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var total int
+	if err := s.db.QueryRow(`SELECT COUNT(*) FROM login_events`+where, args...).Scan(&total); err != nil {
+		return nil, 0, fault.Custom(
+			http.StatusInternalServerError,
+			fault.ErrInternalServer,
+			fmt.Sprintf("failed to count login events: %v", err),
+		)
+	}
+
+	listQuery := `SELECT id, user_id, identifier, event, reason, session_id, ip, user_agent, created_at FROM login_events` +
+		where + fmt.Sprintf(" ORDER BY created_at DESC, id LIMIT $%d OFFSET $%d", argPos, argPos+1)
+	args = append(args, query.Limit, (query.Page-1)*query.Limit)
+
+	rows, err := s.db.Query(listQuery, args...)
+	if err != nil {
+		return nil, 0, fault.Custom(
+			http.StatusInternalServerError,
+			fault.ErrInternalServer,
+			fmt.Sprintf("failed to list login events: %v", err),
+		)
+	}
+	defer rows.Close()
+
+	events := []model.LoginEvent{}
+	for rows.Next() {
+		var event model.LoginEvent
+		err := rows.Scan(
+			&event.Id,
+			&event.UserId,
+			&event.Identifier,
+			&event.Event,
+			&event.Reason,
+			&event.SessionId,
+			&event.IP,
+			&event.UserAgent,
+			&event.CreatedAt,
+		)
+		if err != nil {
+			return nil, 0, fault.Custom(
+				http.StatusInternalServerError,
+				fault.ErrInternalServer,
+				fmt.Sprintf("failed to read login event: %v", err),
+			)
+		}
+		events = append(events, event)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, 0, fault.Custom(
+			http.StatusInternalServerError,
+			fault.ErrInternalServer,
+			fmt.Sprintf("failed to list login events: %v", err),
+		)
+	}
+
+	return events, total, nil
+}
+
+func (s *store) PruneLoginEvents(before time.Time, limit int) (int64, error) {
+	res, err := s.db.Exec(`DELETE FROM login_events WHERE id IN (
+		SELECT id FROM login_events WHERE created_at < $1 LIMIT $2
+	)`, before, limit)
+	if err != nil {
+		return 0, fault.Custom(
+			http.StatusInternalServerError,
+			fault.ErrInternalServer,
+			fmt.Sprintf("failed to prune login events before %s: %v", before.Format(time.RFC3339), err),
+		)
+	}
+
+	n, _ := res.RowsAffected()
+	return n, nil
+}
@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Reza1878/goesclearning/user-service/model"
+	"github.com/google/uuid"
+)
+
+// memoryStore keeps login events in process with the same semantics as
+// the Postgres store.
+type memoryStore struct {
+	mu     sync.RWMutex
+	events []model.LoginEvent
+}
+
+func NewMemoryStore() LoginEventRepository {
+	return &memoryStore{}
+}
+
+func (s *memoryStore) InsertLoginEvent(event model.LoginEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	event.Id = uuid.New()
+	event.CreatedAt = &now
+	s.events = append(s.events, event)
+	return nil
+}
+
+func (s *memoryStore) ListLoginEvents(userId uuid.UUID, query model.LoginEventQuery) ([]model.LoginEvent, int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	matched := []model.LoginEvent{}
+	for _, event := range s.events {
+		if userId != uuid.Nil && (event.UserId == nil || *event.UserId != userId) {
+			continue
+		}
+		if query.Event != "" && event.Event != query.Event {
+			continue
+		}
+		matched = append(matched, event)
+	}
+
+	sort.SliceStable(matched, func(i, j int) bool {
+		return matched[i].CreatedAt.After(*matched[j].CreatedAt)
+	})
+
+	start := (query.Page - 1) * query.Limit
+	if start > len(matched) {
+		start = len(matched)
+	}
+	end := start + query.Limit
+	if end > len(matched) {
+		end = len(matched)
+	}
+
+	return matched[start:end], len(matched), nil
+}
+
+func (s *memoryStore) PruneLoginEvents(before time.Time, limit int) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	kept := s.events[:0]
+	var pruned int64
+	for _, event := range s.events {
+		if event.CreatedAt.Before(before) && pruned < int64(limit) {
+			pruned++
+			continue
+		}
+		kept = append(kept, event)
+	}
+	s.events = kept
+	return pruned, nil
+}
@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Reza1878/goesclearning/user-service/model"
+	"github.com/google/uuid"
+)
+
+// memoryStore keeps sessions in process with the same semantics as the
+// Postgres store.
+type memoryStore struct {
+	mu       sync.RWMutex
+	sessions map[uuid.UUID]model.Session
+}
+
+func NewMemoryStore() SessionRepository {
+	return &memoryStore{sessions: map[uuid.UUID]model.Session{}}
+}
+
+func (s *memoryStore) CreateSession(session model.Session) (*uuid.UUID, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	session.Id = uuid.New()
+	session.CreatedAt = &now
+	session.LastUsedAt = &now
+	s.sessions[session.Id] = session
+
+	return &session.Id, nil
+}
+
+func (s *memoryStore) GetSession(id uuid.UUID) (*model.Session, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	session, ok := s.sessions[id]
+	if !ok {
+		return nil, sessionNotFound(id)
+	}
+	return &session, nil
+}
+
+func (s *memoryStore) ListSessions(userId uuid.UUID) ([]model.Session, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	now := time.Now()
+	sessions := []model.Session{}
+	for _, session := range s.sessions {
+		if session.UserId == userId && session.RevokedAt == nil && session.ExpiresAt.After(now) {
+			sessions = append(sessions, session)
+		}
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].CreatedAt.After(*sessions[j].CreatedAt)
+	})
+	return sessions, nil
+}
+
+func (s *memoryStore) RevokeSession(userId, id uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, ok := s.sessions[id]
+	if !ok || session.UserId != userId || session.RevokedAt != nil {
+		return sessionNotFound(id)
+	}
+
+	now := time.Now()
+	session.RevokedAt = &now
+	s.sessions[id] = session
+	return nil
+}
+
+func (s *memoryStore) RotateRefreshToken(id, current, next uuid.UUID, expiresAt time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, ok := s.sessions[id]
+	if !ok || session.RevokedAt != nil || session.RefreshTokenId != current {
+		return false, nil
+	}
+
+	now := time.Now()
+	session.RefreshTokenId = next
+	session.ExpiresAt = &expiresAt
+	session.LastUsedAt = &now
+	s.sessions[id] = session
+	return true, nil
+}
+
+func (s *memoryStore) TouchSession(id uuid.UUID, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if session, ok := s.sessions[id]; ok {
+		session.LastUsedAt = &at
+		s.sessions[id] = session
+	}
+	return nil
+}
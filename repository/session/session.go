@@ -0,0 +1,208 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Reza1878/goesclearning/user-service/helper/fault"
+	"github.com/Reza1878/goesclearning/user-service/model"
+	"github.com/google/uuid"
+)
+
+type store struct {
+	db *sql.DB
+}
+
+func NewStore(db *sql.DB) *store {
+	return &store{db: db}
+}
+
+type SessionRepository interface {
+	CreateSession(session model.Session) (*uuid.UUID, error)
+	GetSession(id uuid.UUID) (*model.Session, error)
+	// ListSessions returns the user's sessions that are neither revoked
+	// nor expired, newest first.
+	ListSessions(userId uuid.UUID) ([]model.Session, error)
+	// RevokeSession revokes a live session of the user; any other id is
+	// a 404.
+	RevokeSession(userId, id uuid.UUID) error
+	TouchSession(id uuid.UUID, at time.Time) error
+	// RotateRefreshToken replaces the session's refresh token id current
+	// with next and extends the session to expiresAt. It reports false,
+	// without changing anything, when current is no longer the latest id
+	// or the session was revoked.
+	RotateRefreshToken(id, current, next uuid.UUID, expiresAt time.Time) (bool, error)
+}
+
+const sessionColumns = `id, user_id, token_family, refresh_token_id, device_name, user_agent, ip, created_at, last_used_at, expires_at, revoked_at`
+
+func (s *store) CreateSession(session model.Session) (*uuid.UUID, error) {
+	query := `INSERT INTO user_sessions(user_id, token_family, refresh_token_id, device_name, user_agent, ip, expires_at)
+		VALUES($1, $2, $3, $4, $5, $6, $7) RETURNING id`
+
+	var id uuid.UUID
+	err := s.db.QueryRow(query,
+		session.UserId,
+		session.FamilyId,
+		session.RefreshTokenId,
+		session.DeviceName,
+		session.UserAgent,
+		session.IP,
+		session.ExpiresAt,
+	).Scan(&id)
+	if err != nil {
+		return nil, fault.Custom(
+			http.StatusInternalServerError,
+			fault.ErrInternalServer,
+			fmt.Sprintf("failed to create session for user %s: %v", session.UserId, err),
+		)
+	}
+
+	return &id, nil
+}
+
+func (s *store) GetSession(id uuid.UUID) (*model.Session, error) {
+	row := s.db.QueryRow(`SELECT `+sessionColumns+` FROM user_sessions WHERE id = $1`, id)
+
+	session, err := scanSession(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, sessionNotFound(id)
+		}
+		return nil, fault.Custom(
+			http.StatusInternalServerError,
+			fault.ErrInternalServer,
+			fmt.Sprintf("failed to get session %s: %v", id, err),
+		)
+	}
+
+	return session, nil
+}
+
+func (s *store) ListSessions(userId uuid.UUID) ([]model.Session, error) {
+	rows, err := s.db.Query(`SELECT `+sessionColumns+` FROM user_sessions
+		WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > $2
+		ORDER BY created_at DESC`, userId, time.Now())
+	if err != nil {
+		return nil, fault.Custom(
+			http.StatusInternalServerError,
+			fault.ErrInternalServer,
+			fmt.Sprintf("failed to list sessions of user %s: %v", userId, err),
+		)
+	}
+	defer rows.Close()
+
+	sessions := []model.Session{}
+	for rows.Next() {
+		session, err := scanSession(rows)
+		if err != nil {
+			return nil, fault.Custom(
+				http.StatusInternalServerError,
+				fault.ErrInternalServer,
+				fmt.Sprintf("failed to read session of user %s: %v", userId, err),
+			)
+		}
+		sessions = append(sessions, *session)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fault.Custom(
+			http.StatusInternalServerError,
+			fault.ErrInternalServer,
+			fmt.Sprintf("failed to list sessions of user %s: %v", userId, err),
+		)
+	}
+
+	return sessions, nil
+}
+
+func (s *store) RevokeSession(userId, id uuid.UUID) error {
+	res, err := s.db.Exec(`UPDATE user_sessions SET revoked_at = $3
+		WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL`, id, userId, time.Now())
+	if err != nil {
+		return fault.Custom(
+			http.StatusInternalServerError,
+			fault.ErrInternalServer,
+			fmt.Sprintf("failed to revoke session %s: %v", id, err),
+		)
+	}
+
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return sessionNotFound(id)
+	}
+
+	return nil
+}
+
+func (s *store) TouchSession(id uuid.UUID, at time.Time) error {
+	if _, err := s.db.Exec(`UPDATE user_sessions SET last_used_at = $2 WHERE id = $1`, id, at); err != nil {
+		return fault.Custom(
+			http.StatusInternalServerError,
+			fault.ErrInternalServer,
+			fmt.Sprintf("failed to touch session %s: %v", id, err),
+		)
+	}
+
+	return nil
+}
+
+func (s *store) RotateRefreshToken(id, current, next uuid.UUID, expiresAt time.Time) (bool, error) {
+	res, err := s.db.Exec(`UPDATE user_sessions SET refresh_token_id = $3, expires_at = $4, last_used_at = $5
+		WHERE id = $1 AND refresh_token_id = $2 AND revoked_at IS NULL`, id, current, next, expiresAt, time.Now())
+	if err != nil {
+		return false, fault.Custom(
+			http.StatusInternalServerError,
+			fault.ErrInternalServer,
+			fmt.Sprintf("failed to rotate refresh token of session %s: %v", id, err),
+		)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fault.Custom(
+			http.StatusInternalServerError,
+			fault.ErrInternalServer,
+			fmt.Sprintf("failed to rotate refresh token of session %s: %v", id, err),
+		)
+	}
+	return n == 1, nil
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanSession(row scanner) (*model.Session, error) {
+	var session model.Session
+	// sessions created before refresh rotation have no token id
+	var refreshTokenId uuid.NullUUID
+	err := row.Scan(
+		&session.Id,
+		&session.UserId,
+		&session.FamilyId,
+		&refreshTokenId,
+		&session.DeviceName,
+		&session.UserAgent,
+		&session.IP,
+		&session.CreatedAt,
+		&session.LastUsedAt,
+		&session.ExpiresAt,
+		&session.RevokedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	session.RefreshTokenId = refreshTokenId.UUID
+
+	return &session, nil
+}
+
+func sessionNotFound(id uuid.UUID) error {
+	return fault.Custom(
+		http.StatusNotFound,
+		fault.ErrNotFound,
+		fmt.Sprintf("session %s not found", id),
+	)
+}
@@ -0,0 +1,241 @@
+package repository
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Reza1878/goesclearning/user-service/helper/cache"
+	"github.com/Reza1878/goesclearning/user-service/helper/fault"
+	"github.com/Reza1878/goesclearning/user-service/model"
+	"github.com/google/uuid"
+	"golang.org/x/sync/singleflight"
+)
+
+// cachedUser is what lands in the cache. The password hash never goes in
+// clear text; it is sealed with AES-GCM under the cache key.
+type cachedUser struct {
+	Missing      bool        `json:"missing,omitempty"`
+	User         *model.User `json:"user,omitempty"`
+	PasswordHash []byte      `json:"password_hash,omitempty"`
+}
+
+// cachedStore is a read-through cache in front of a UserRepository for
+// lookups by id or by email. Misses are cached for a shorter time so a
+// burst of logins for an unknown email does not reach Postgres. Every
+// write must drop the keys of the user it touches.
+type cachedStore struct {
+	UserRepository
+
+	cache       cache.Store
+	aead        cipher.AEAD
+	ttl         time.Duration
+	negativeTTL time.Duration
+	group       singleflight.Group
+}
+
+// NewCachedStore wraps inner. key must be 32 bytes and is used to seal
+// password hashes.
+func NewCachedStore(inner UserRepository, store cache.Store, key []byte, ttl, negativeTTL time.Duration) (UserRepository, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user cache key: %w", err)
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user cache key: %w", err)
+	}
+
+	return &cachedStore{
+		UserRepository: inner,
+		cache:          store,
+		aead:           aead,
+		ttl:            ttl,
+		negativeTTL:    negativeTTL,
+	}, nil
+}
+
+func userIDKey(id uuid.UUID) string {
+	return "user:id:" + id.String()
+}
+
+// userEmailKey is case-insensitive, like the email lookup itself.
+func userEmailKey(email string) string {
+	return "user:email:" + strings.ToLower(email)
+}
+
+// lookupKey returns the cache key for lookups by id alone or email alone;
+// any other combination goes straight to the repository.
+func lookupKey(req model.GetUserDetailRequest) string {
+	switch {
+	case req.Name != "" || req.Username != "":
+		return ""
+	case req.UserId != uuid.Nil && req.Email == "":
+		return userIDKey(req.UserId)
+	case req.Email != "" && req.UserId == uuid.Nil:
+		return userEmailKey(req.Email)
+	}
+	return ""
+}
+
+func (s *cachedStore) InsertUser(user model.RegisterUser) (*uuid.UUID, error) {
+	id, err := s.UserRepository.InsertUser(user)
+	if err != nil {
+		return nil, err
+	}
+
+	// the email may be cached as missing
+	s.invalidate(context.Background(), userEmailKey(user.Email), userIDKey(*id))
+	return id, nil
+}
+
+func (s *cachedStore) GetUserDetail(req model.GetUserDetailRequest) (*model.User, error) {
+	key := lookupKey(req)
+	if key == "" {
+		return s.UserRepository.GetUserDetail(req)
+	}
+
+	ctx := context.Background()
+
+	if entry, ok := s.get(ctx, key); ok {
+		if entry.Missing {
+			return nil, userNotFound()
+		}
+		return entry.User, nil
+	}
+
+	res, err, _ := s.group.Do(key, func() (interface{}, error) {
+		user, err := s.UserRepository.GetUserDetail(req)
+		if err != nil {
+			if isNotFound(err) {
+				s.storeMissing(ctx, key, req)
+			}
+			return nil, err
+		}
+
+		s.store(ctx, user)
+		return user, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// callers clear the password before responding, so each gets a copy
+	user := *res.(*model.User)
+	return &user, nil
+}
+
+func (s *cachedStore) get(ctx context.Context, key string) (*cachedUser, bool) {
+	raw, err := s.cache.Get(ctx, key)
+	if err != nil {
+		if !errors.Is(err, cache.ErrMiss) {
+			slog.Warn("failed to read user cache", slog.String("key", key), slog.Any("error", err))
+		}
+		return nil, false
+	}
+
+	var entry cachedUser
+	if err := json.Unmarshal(raw, &entry); err != nil || (!entry.Missing && entry.User == nil) {
+		slog.Warn("corrupt user cache entry", slog.String("key", key))
+		return nil, false
+	}
+
+	if entry.User != nil {
+		password, err := s.open(entry.PasswordHash)
+		if err != nil {
+			// sealed under a previous key
+			return nil, false
+		}
+		entry.User.Password = password
+	}
+
+	return &entry, true
+}
+
+func (s *cachedStore) store(ctx context.Context, user *model.User) {
+	sealed, err := s.seal(user.Password)
+	if err != nil {
+		slog.Warn("failed to seal user cache entry", slog.Any("error", err))
+		return
+	}
+
+	clean := *user
+	clean.Password = ""
+	entry := cachedUser{User: &clean, PasswordHash: sealed}
+
+	s.set(ctx, userIDKey(user.Id), entry, s.ttl)
+	s.set(ctx, userEmailKey(user.Email), entry, s.ttl)
+}
+
+// storeMissing caches key as missing. An InsertUser that committed while
+// the lookup was running may already have invalidated key, so the lookup
+// is repeated after the write and the entry dropped unless the user is
+// still missing. An insert committing later invalidates the entry itself.
+func (s *cachedStore) storeMissing(ctx context.Context, key string, req model.GetUserDetailRequest) {
+	s.set(ctx, key, cachedUser{Missing: true}, s.negativeTTL)
+
+	if _, err := s.UserRepository.GetUserDetail(req); !isNotFound(err) {
+		if err := s.cache.Delete(ctx, key); err != nil {
+			slog.Error("failed to invalidate user cache", slog.String("key", key), slog.Any("error", err))
+		}
+	}
+}
+
+func (s *cachedStore) set(ctx context.Context, key string, entry cachedUser, ttl time.Duration) {
+	if err := cache.SetJSON(ctx, s.cache, key, entry, ttl); err != nil {
+		slog.Warn("failed to write user cache", slog.String("key", key), slog.Any("error", err))
+	}
+}
+
+func (s *cachedStore) invalidate(ctx context.Context, keys ...string) {
+	for _, key := range keys {
+		s.group.Forget(key)
+	}
+
+	if err := s.cache.Delete(ctx, keys...); err != nil {
+		slog.Error("failed to invalidate user cache", slog.Any("keys", keys), slog.Any("error", err))
+	}
+}
+
+func (s *cachedStore) seal(plain string) ([]byte, error) {
+	nonce := make([]byte, s.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return s.aead.Seal(nonce, nonce, []byte(plain), nil), nil
+}
+
+func (s *cachedStore) open(sealed []byte) (string, error) {
+	size := s.aead.NonceSize()
+	if len(sealed) < size {
+		return "", errors.New("sealed value too short")
+	}
+
+	plain, err := s.aead.Open(nil, sealed[:size], sealed[size:], nil)
+	if err != nil {
+		return "", err
+	}
+	return string(plain), nil
+}
+
+func isNotFound(err error) bool {
+	var detailed *fault.DetailedError
+	return errors.As(err, &detailed) && detailed.Code == fault.ErrNotFound
+}
+
+func userNotFound() error {
+	return fault.Custom(
+		http.StatusNotFound,
+		fault.ErrNotFound,
+		"user not found based on provided filters",
+	)
+}
@@ -0,0 +1,225 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Reza1878/goesclearning/user-service/helper/cache"
+	"github.com/Reza1878/goesclearning/user-service/model"
+	"github.com/google/uuid"
+)
+
+const testPasswordHash = "$argon2id$v=19$m=65536,t=1,p=4$c2FsdA$aGFzaA"
+
+// countingStore counts the lookups that get past the cache.
+type countingStore struct {
+	UserRepository
+	lookups int
+}
+
+func (s *countingStore) GetUserDetail(req model.GetUserDetailRequest) (*model.User, error) {
+	s.lookups++
+	return s.UserRepository.GetUserDetail(req)
+}
+
+func newCachedTestStore(t *testing.T, store cache.Store, key []byte) (UserRepository, *countingStore) {
+	t.Helper()
+
+	inner := &countingStore{UserRepository: NewMemoryStore()}
+	cached, err := NewCachedStore(inner, store, key, time.Minute, time.Minute)
+	if err != nil {
+		t.Fatalf("NewCachedStore: %v", err)
+	}
+	return cached, inner
+}
+
+func testKey(b byte) []byte {
+	return bytes.Repeat([]byte{b}, 32)
+}
+
+func TestCachedStoreLookups(t *testing.T) {
+	tests := []struct {
+		name string
+		req  func(id uuid.UUID) model.GetUserDetailRequest
+	}{
+		{"by id", func(id uuid.UUID) model.GetUserDetailRequest { return model.GetUserDetailRequest{UserId: id} }},
+		{"by email", func(uuid.UUID) model.GetUserDetailRequest {
+			return model.GetUserDetailRequest{Email: "alice@example.com"}
+		}},
+		{"by email in other case", func(uuid.UUID) model.GetUserDetailRequest {
+			return model.GetUserDetailRequest{Email: "Alice@Example.com"}
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			users, inner := newCachedTestStore(t, cache.NewMemory(100), testKey(1))
+
+			id, err := users.InsertUser(model.RegisterUser{Name: "alice", Email: "alice@example.com", Password: testPasswordHash})
+			if err != nil {
+				t.Fatalf("InsertUser: %v", err)
+			}
+
+			for i := 0; i < 3; i++ {
+				user, err := users.GetUserDetail(tt.req(*id))
+				if err != nil {
+					t.Fatalf("lookup %d: %v", i, err)
+				}
+				if user.Id != *id || user.Password != testPasswordHash {
+					t.Fatalf("lookup %d returned %+v", i, user)
+				}
+			}
+
+			if inner.lookups != 1 {
+				t.Fatalf("repository lookups = %d, want 1", inner.lookups)
+			}
+		})
+	}
+}
+
+func TestCachedStoreBypassesCombinedFilters(t *testing.T) {
+	users, inner := newCachedTestStore(t, cache.NewMemory(100), testKey(1))
+
+	if _, err := users.InsertUser(model.RegisterUser{Name: "alice", Email: "alice@example.com", Password: testPasswordHash}); err != nil {
+		t.Fatalf("InsertUser: %v", err)
+	}
+
+	req := model.GetUserDetailRequest{Name: "alice", Email: "alice@example.com"}
+	for i := 0; i < 2; i++ {
+		if _, err := users.GetUserDetail(req); err != nil {
+			t.Fatalf("lookup %d: %v", i, err)
+		}
+	}
+
+	if inner.lookups != 2 {
+		t.Fatalf("repository lookups = %d, want 2", inner.lookups)
+	}
+}
+
+func TestCachedStoreNegativeCaching(t *testing.T) {
+	users, inner := newCachedTestStore(t, cache.NewMemory(100), testKey(1))
+	req := model.GetUserDetailRequest{Email: "bob@example.com"}
+
+	for i := 0; i < 3; i++ {
+		if _, err := users.GetUserDetail(req); !isNotFound(err) {
+			t.Fatalf("lookup %d: got %v, want not found", i, err)
+		}
+	}
+	// the first miss is read twice, to catch a racing insert
+	if inner.lookups != 2 {
+		t.Fatalf("repository lookups = %d, want 2", inner.lookups)
+	}
+
+	// registering the email must drop the cached miss
+	id, err := users.InsertUser(model.RegisterUser{Name: "bob", Email: "Bob@example.com", Password: testPasswordHash})
+	if err != nil {
+		t.Fatalf("InsertUser: %v", err)
+	}
+
+	user, err := users.GetUserDetail(req)
+	if err != nil {
+		t.Fatalf("lookup after insert: %v", err)
+	}
+	if user.Id != *id {
+		t.Fatalf("lookup after insert returned %s, want %s", user.Id, *id)
+	}
+	if inner.lookups != 3 {
+		t.Fatalf("repository lookups = %d, want 3", inner.lookups)
+	}
+}
+
+func TestCachedStoreSealsPasswordHash(t *testing.T) {
+	store := cache.NewMemory(100)
+	users, inner := newCachedTestStore(t, store, testKey(1))
+
+	id, err := users.InsertUser(model.RegisterUser{Name: "alice", Email: "alice@example.com", Password: testPasswordHash})
+	if err != nil {
+		t.Fatalf("InsertUser: %v", err)
+	}
+	if _, err := users.GetUserDetail(model.GetUserDetailRequest{UserId: *id}); err != nil {
+		t.Fatalf("GetUserDetail: %v", err)
+	}
+
+	raw, err := store.Get(context.Background(), userIDKey(*id))
+	if err != nil {
+		t.Fatalf("cache entry missing: %v", err)
+	}
+	if bytes.Contains(raw, []byte(testPasswordHash)) {
+		t.Fatalf("cache entry holds the password hash in clear text: %s", raw)
+	}
+
+	// a second store with the same key opens the sealed hash
+	reader, readerInner := newCachedTestStore(t, store, testKey(1))
+	user, err := reader.GetUserDetail(model.GetUserDetailRequest{UserId: *id})
+	if err != nil {
+		t.Fatalf("GetUserDetail with the same key: %v", err)
+	}
+	if user.Password != testPasswordHash {
+		t.Fatalf("opened password = %q, want %q", user.Password, testPasswordHash)
+	}
+	if readerInner.lookups != 0 {
+		t.Fatalf("repository lookups = %d, want a cache hit", readerInner.lookups)
+	}
+
+	// after a key rotation the entry cannot be opened and the lookup
+	// falls through to the repository
+	rotated, rotatedInner := newCachedTestStore(t, store, testKey(2))
+	if _, err := rotated.GetUserDetail(model.GetUserDetailRequest{UserId: *id}); !isNotFound(err) {
+		t.Fatalf("lookup with rotated key: got %v, want a miss in the empty repository", err)
+	}
+	// a miss, so the repository is read twice
+	if rotatedInner.lookups != 2 {
+		t.Fatalf("repository lookups = %d, want 2", rotatedInner.lookups)
+	}
+	if inner.lookups != 1 {
+		t.Fatalf("original repository lookups = %d, want 1", inner.lookups)
+	}
+}
+
+// racingStore lets a registration commit while a lookup is running: the
+// first lookup reads, then runs insert, then returns what it read.
+type racingStore struct {
+	UserRepository
+	insert func()
+}
+
+func (s *racingStore) GetUserDetail(req model.GetUserDetailRequest) (*model.User, error) {
+	user, err := s.UserRepository.GetUserDetail(req)
+	if insert := s.insert; insert != nil {
+		s.insert = nil
+		insert()
+	}
+	return user, err
+}
+
+func TestCachedStoreRegisterDuringMissingLookup(t *testing.T) {
+	inner := &racingStore{UserRepository: NewMemoryStore()}
+	users, err := NewCachedStore(inner, cache.NewMemory(100), testKey(1), time.Minute, time.Minute)
+	if err != nil {
+		t.Fatalf("NewCachedStore: %v", err)
+	}
+
+	var id *uuid.UUID
+	inner.insert = func() {
+		id, err = users.InsertUser(model.RegisterUser{Name: "alice", Email: "alice@example.com", Password: testPasswordHash})
+		if err != nil {
+			t.Fatalf("InsertUser: %v", err)
+		}
+	}
+
+	// the lookup read before the insert, so it still misses
+	if _, err := users.GetUserDetail(model.GetUserDetailRequest{Email: "alice@example.com"}); !isNotFound(err) {
+		t.Fatalf("racing lookup: got %v, want not found", err)
+	}
+
+	// but its miss must not outlive the insert's invalidation
+	user, err := users.GetUserDetail(model.GetUserDetailRequest{Email: "alice@example.com"})
+	if err != nil {
+		t.Fatalf("lookup after register: %v", err)
+	}
+	if user.Id != *id {
+		t.Fatalf("lookup after register returned %s, want %s", user.Id, *id)
+	}
+}
@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"fmt"
+
+	"github.com/Reza1878/goesclearning/user-service/model"
+)
+
+// EmailDuplicate is a set of accounts whose emails normalise to the same
+// address, oldest first.
+type EmailDuplicate struct {
+	Normalized string
+	Users      []model.User
+}
+
+// EmailReport is the result of scanning every stored email.
+type EmailReport struct {
+	Duplicates []EmailDuplicate
+	// Unnormalized lists accounts whose stored email differs from its
+	// normalised form, e.g. rows written before normalisation existed.
+	Unnormalized []model.User
+	// Invalid lists accounts whose email cannot be normalised at all.
+	Invalid []model.User
+}
+
+// FindEmailDuplicates reads every user and groups them by normalize. It
+// is meant for one-off reports, not for request handling.
+func (s *store) FindEmailDuplicates(normalize func(string) (string, error)) (*EmailReport, error) {
+	rows, err := s.db.Query(`SELECT id, name, email, created_at FROM users ORDER BY created_at, id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list users: %w", err)
+	}
+	defer rows.Close()
+
+	report := &EmailReport{}
+	groups := map[string]*EmailDuplicate{}
+	var order []string
+
+	for rows.Next() {
+		var user model.User
+		if err := rows.Scan(&user.Id, &user.Name, &user.Email, &user.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan user: %w", err)
+		}
+
+		normalized, err := normalize(user.Email)
+		if err != nil {
+			report.Invalid = append(report.Invalid, user)
+			continue
+		}
+		if normalized != user.Email {
+			report.Unnormalized = append(report.Unnormalized, user)
+		}
+
+		group, ok := groups[normalized]
+		if !ok {
+			group = &EmailDuplicate{Normalized: normalized}
+			groups[normalized] = group
+			order = append(order, normalized)
+		}
+		group.Users = append(group.Users, user)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list users: %w", err)
+	}
+
+	for _, normalized := range order {
+		if group := groups[normalized]; len(group.Users) > 1 {
+			report.Duplicates = append(report.Duplicates, *group)
+		}
+	}
+
+	return report, nil
+}
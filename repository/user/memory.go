@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Reza1878/goesclearning/user-service/helper/fault"
+	"github.com/Reza1878/goesclearning/user-service/model"
+	"github.com/google/uuid"
+)
+
+// memoryStore keeps users in process with the same semantics as the
+// Postgres store: emails are unique, lookups AND their filters, and a
+// lookup that matches nothing is a 404. Emails compare case-insensitively,
+// like the citext column.
+type memoryStore struct {
+	mu         sync.RWMutex
+	users      map[uuid.UUID]model.User
+	byEmail    map[string]uuid.UUID
+	byUsername map[string]uuid.UUID
+}
+
+func NewMemoryStore() UserRepository {
+	return &memoryStore{
+		users:      map[uuid.UUID]model.User{},
+		byEmail:    map[string]uuid.UUID{},
+		byUsername: map[string]uuid.UUID{},
+	}
+}
+
+func (s *memoryStore) InsertUser(user model.RegisterUser) (*uuid.UUID, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.byEmail[strings.ToLower(user.Email)]; ok {
+		return nil, emailAlreadyRegistered(user.Email)
+	}
+	if _, ok := s.byUsername[user.Username]; ok && user.Username != "" {
+		return nil, usernameTaken(user.Username)
+	}
+
+	now := time.Now()
+	id := uuid.New()
+	s.users[id] = model.User{
+		Id:        id,
+		Name:      user.Name,
+		Email:     user.Email,
+		Username:  user.Username,
+		Password:  user.Password,
+		Role:      model.RoleUser,
+		CreatedAt: &now,
+		UpdatedAt: &now,
+	}
+	s.byEmail[strings.ToLower(user.Email)] = id
+	if user.Username != "" {
+		s.byUsername[user.Username] = id
+	}
+
+	return &id, nil
+}
+
+func (s *memoryStore) GetUserDetail(req model.GetUserDetailRequest) (*model.User, error) {
+	if req.UserId == uuid.Nil && req.Name == "" && req.Email == "" && req.Username == "" {
+		return nil, fault.Custom(
+			http.StatusBadRequest,
+			fault.ErrBadRequest,
+			"at least one filter (user_id, name, email, or username) must be provided",
+		)
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, user := range s.users {
+		if req.UserId != uuid.Nil && user.Id != req.UserId {
+			continue
+		}
+		if req.Name != "" && user.Name != req.Name {
+			continue
+		}
+		if req.Email != "" && !strings.EqualFold(user.Email, req.Email) {
+			continue
+		}
+		if req.Username != "" && user.Username != req.Username {
+			continue
+		}
+
+		return &user, nil
+	}
+
+	return nil, fault.Custom(
+		http.StatusNotFound,
+		fault.ErrNotFound,
+		"user not found based on provided filters",
+	)
+}
+
+func (s *memoryStore) UsernameExists(username string) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	_, ok := s.byUsername[username]
+	return ok, nil
+}
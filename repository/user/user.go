@@ -2,15 +2,24 @@ package repository
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"net/http"
 	"strings"
 
 	"github.com/Reza1878/goesclearning/user-service/helper/fault"
+	"github.com/Reza1878/goesclearning/user-service/helper/outbox"
 	"github.com/Reza1878/goesclearning/user-service/model"
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
+// uniqueViolation is the Postgres error code for a unique constraint or
+// index rejecting a row.
+const uniqueViolation = "23505"
+
+const usernameConstraint = "users_username_key"
+
 type store struct {
 	db *sql.DB
 }
@@ -22,7 +31,7 @@ func NewStore(db *sql.DB) *store {
 type UserRepository interface {
 	InsertUser(user model.RegisterUser) (*uuid.UUID, error)
 	GetUserDetail(req model.GetUserDetailRequest) (*model.User, error)
-	UserExistsByName(name string) (bool, error)
+	UsernameExists(username string) (bool, error)
 }
 
 func (s *store) InsertUser(user model.RegisterUser) (*uuid.UUID, error) {
@@ -36,14 +45,39 @@ func (s *store) InsertUser(user model.RegisterUser) (*uuid.UUID, error) {
 	}
 	defer tx.Rollback()
 
-	baseQuery := `INSERT INTO users(name, email, password) VALUES($1, $2, $3) RETURNING id`
+	baseQuery := `INSERT INTO users(name, email, password, username) VALUES($1, $2, $3, NULLIF($4, '')) RETURNING id`
 
 	var userId uuid.UUID
-	if err := tx.QueryRow(baseQuery, user.Name, user.Email, user.Password).Scan(&userId); err != nil {
+	if err := tx.QueryRow(baseQuery, user.Name, user.Email, user.Password, user.Username).Scan(&userId); err != nil {
 		tx.Rollback()
+
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+			if pqErr.Constraint == usernameConstraint {
+				return nil, usernameTaken(user.Username)
+			}
+			return nil, emailAlreadyRegistered(user.Email)
+		}
 		return nil, fault.Custom(http.StatusUnprocessableEntity, fault.ErrUnprocessable, fmt.Sprintf("failed to insert user: %v", err.Error()))
 	}
 
+	event, err := outbox.NewEvent(outbox.TopicUserRegistered, userId.String(), model.UserRegisteredEvent{
+		UserId:   userId,
+		Name:     user.Name,
+		Email:    user.Email,
+		Username: user.Username,
+	})
+	if err == nil {
+		err = outbox.Insert(tx, event)
+	}
+	if err != nil {
+		return nil, fault.Custom(
+			http.StatusInternalServerError,
+			fault.ErrInternalServer,
+			fmt.Sprintf("failed to insert user: %v", err),
+		)
+	}
+
 	if err := tx.Commit(); err != nil {
 		tx.Rollback()
 		return nil, fault.Custom(
@@ -57,7 +91,7 @@ func (s *store) InsertUser(user model.RegisterUser) (*uuid.UUID, error) {
 }
 
 func (s *store) GetUserDetail(req model.GetUserDetailRequest) (*model.User, error) {
-	baseQuery := `SELECT id, password, name, email, created_at, updated_at FROM users WHERE `
+	baseQuery := `SELECT id, password, name, email, COALESCE(username, ''), role, created_at, updated_at FROM users WHERE `
 	var args []interface{}
 	var conditions []string
 
@@ -81,11 +115,17 @@ func (s *store) GetUserDetail(req model.GetUserDetailRequest) (*model.User, erro
 		argPos++
 	}
 
+	if req.Username != "" {
+		conditions = append(conditions, fmt.Sprintf("username = $%d", argPos))
+		args = append(args, req.Username)
+		argPos++
+	}
+
 	if len(conditions) == 0 {
 		return nil, fault.Custom(
 			http.StatusBadRequest,
 			fault.ErrBadRequest,
-			"at least one filter (user_id, name, or email) must be provided",
+			"at least one filter (user_id, name, email, or username) must be provided",
 		)
 	}
 
@@ -98,6 +138,8 @@ func (s *store) GetUserDetail(req model.GetUserDetailRequest) (*model.User, erro
 		&user.Password,
 		&user.Name,
 		&user.Email,
+		&user.Username,
+		&user.Role,
 		&user.CreatedAt,
 		&user.UpdatedAt,
 	)
@@ -121,18 +163,32 @@ func (s *store) GetUserDetail(req model.GetUserDetailRequest) (*model.User, erro
 	return &user, nil
 }
 
-func (s *store) UserExistsByName(name string) (bool, error) {
-	baseQuery := `SELECT COUNT(*) FROM users WHERE name = $1`
-
-	var count int
-	err := s.db.QueryRow(baseQuery, name).Scan(&count)
+func (s *store) UsernameExists(username string) (bool, error) {
+	var exists bool
+	err := s.db.QueryRow(`SELECT EXISTS(SELECT 1 FROM users WHERE username = $1)`, username).Scan(&exists)
 	if err != nil {
 		return false, fault.Custom(
 			http.StatusInternalServerError,
 			fault.ErrInternalServer,
-			fmt.Sprintf("failed to count users by name '%s': %v", name, err),
+			fmt.Sprintf("failed to check username '%s': %v", username, err),
 		)
 	}
 
-	return count > 0, nil
+	return exists, nil
+}
+
+func usernameTaken(username string) error {
+	return fault.Custom(
+		http.StatusConflict,
+		fault.ErrUsernameTaken,
+		fmt.Sprintf("failed to insert user: username %s already taken", username),
+	)
+}
+
+func emailAlreadyRegistered(email string) error {
+	return fault.Custom(
+		http.StatusConflict,
+		fault.ErrEmailAlreadyRegistered,
+		fmt.Sprintf("failed to insert user: email %s already registered", email),
+	)
 }
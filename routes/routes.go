@@ -2,53 +2,107 @@ package routes
 
 import (
 	"fmt"
-	"log"
+	"log/slog"
+	"path"
 	"strings"
 
+	"github.com/Reza1878/goesclearning/user-service/config"
+	auditHandlers "github.com/Reza1878/goesclearning/user-service/handler/audit"
 	productHandlers "github.com/Reza1878/goesclearning/user-service/handler/product"
 	handlers "github.com/Reza1878/goesclearning/user-service/handler/user"
+	"github.com/Reza1878/goesclearning/user-service/helper/cache"
 	"github.com/Reza1878/goesclearning/user-service/middlewares"
 
 	"github.com/gin-gonic/gin"
-	"github.com/spf13/viper"
 )
 
 type Routes struct {
 	Router  *gin.Engine
-	User    *handlers.Handler
-	Product *productHandlers.Handler
+	BaseURL string
+	CORS    config.CORSConfig
+	// TrustedProxies may set the client IP that rate limits and login
+	// events see. Empty trusts no proxy.
+	TrustedProxies []string
+	// Sessions validates the login session of every authenticated request.
+	Sessions middlewares.SessionChecker
+	// Limits is the store shared by the rate limiters.
+	Limits    cache.Store
+	RateLimit config.RateLimitConfig
+	User      *handlers.Handler
+	Product   *productHandlers.Handler
+	Audit     *auditHandlers.Handler
 }
 
 func (r *Routes) SetupRoutes() {
+	baseURL := basePath(r.BaseURL)
+
+	corsGroups := make(map[string]config.CORSPolicy, len(r.CORS.Groups))
+	for group, policy := range r.CORS.Groups {
+		corsGroups[path.Join(baseURL, group)] = policy
+	}
+
 	r.Router = gin.New()
-	r.Router.Use(middlewares.EnabledCORS(), middlewares.Logger(r.Router))
+	if err := r.Router.SetTrustedProxies(r.TrustedProxies); err != nil {
+		// the list is validated when the config loads
+		panic(fmt.Sprintf("invalid trusted proxies: %v", err))
+	}
+	r.Router.Use(
+		middlewares.RequestID(),
+		middlewares.Logger(),
+		middlewares.CORS(r.CORS.Default, corsGroups),
+		middlewares.Locale(),
+	)
 
-	r.setupAPIRoutes()
+	r.setupAPIRoutes(baseURL)
 }
 
-func (r *Routes) setupAPIRoutes() {
-	baseURL := viper.GetString("BASE_URL_PATH")
+func basePath(baseURL string) string {
 	if baseURL == "" || baseURL == "/" {
-		baseURL = "/"
-	} else {
-		baseURL = "/" + strings.TrimPrefix(baseURL, "/")
+		return "/"
 	}
+	return "/" + strings.TrimPrefix(baseURL, "/")
+}
 
+func (r *Routes) setupAPIRoutes(baseURL string) {
 	apiGroup := r.Router.Group(baseURL)
 	r.configureUserRoutes(apiGroup)
 	r.configureProductRoutes(apiGroup)
+	r.configureAdminRoutes(apiGroup)
 }
 
 func (r *Routes) configureUserRoutes(router *gin.RouterGroup) {
 	userGroup := router.Group("/user")
 	userGroup.POST("/register", r.User.HandleUserRegister)
 	userGroup.POST("/login", r.User.HandleUserLogin)
+	userGroup.POST("/refresh", r.User.HandleRefresh)
+	userGroup.GET("/username-available",
+		middlewares.RateLimit(r.Limits, "username", r.RateLimit.UsernameCheck, r.RateLimit.UsernameCheckWindow),
+		r.User.HandleUsernameAvailable,
+	)
+	userGroup.GET("/me/products", r.authenticate(), r.Product.ListMyProducts)
+	userGroup.GET("/me/sessions", r.authenticate(), r.User.HandleListSessions)
+	userGroup.DELETE("/me/sessions/:id", r.authenticate(), r.User.HandleRevokeSession)
+	userGroup.GET("/me/security-events", r.authenticate(), r.User.HandleSecurityEvents)
 }
 
 func (r *Routes) configureProductRoutes(router *gin.RouterGroup) {
 	productGroup := router.Group("/product")
-	productGroup.POST("/", r.Product.InsertProduct)
+	productGroup.POST("/", r.authenticate(), r.Product.InsertProduct)
 	productGroup.GET("/", r.Product.ListProduct)
+	productGroup.POST("/reduce", r.authenticate(), r.Product.ReduceProductQty)
+	productGroup.GET("/:id", r.Product.GetProduct)
+	productGroup.PATCH("/:id", r.authenticate(), r.Product.UpdateProduct)
+	productGroup.DELETE("/:id", r.authenticate(), r.Product.DeleteProduct)
+}
+
+func (r *Routes) configureAdminRoutes(router *gin.RouterGroup) {
+	adminGroup := router.Group("/admin", r.authenticate(), middlewares.RequireAdmin())
+	adminGroup.GET("/security-events", r.User.HandleAdminSecurityEvents)
+	adminGroup.GET("/audit", r.Audit.HandleListAudit)
+}
+
+func (r *Routes) authenticate() gin.HandlerFunc {
+	return middlewares.Authenticate(r.Sessions)
 }
 
 func (r *Routes) Run(port string) {
@@ -57,9 +111,9 @@ func (r *Routes) Run(port string) {
 	}
 
 	addr := fmt.Sprintf(":%s", port)
+	slog.Info("server running", slog.String("addr", addr))
+
 	if err := r.Router.Run(addr); err != nil {
 		panic(fmt.Sprintf("[SERVER ERROR] Failed to start the server on port %s: %v", port, err))
 	}
-
-	log.Default().Printf("[INFO] Server running at port: %s", addr)
 }
@@ -0,0 +1,213 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/Reza1878/goesclearning/user-service/helper/breaker"
+	"github.com/Reza1878/goesclearning/user-service/helper/logger"
+	"github.com/Reza1878/goesclearning/user-service/proto/product"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+type StaleFallback struct {
+	Enabled    bool
+	TTL        time.Duration
+	MaxEntries int
+}
+
+// breakerClient guards a ProductServiceClient with a circuit breaker.
+// While the breaker is open calls fail fast with *breaker.OpenError and,
+// when enabled, list calls are answered from the last good response.
+type breakerClient struct {
+	product.ProductServiceClient
+
+	breaker *breaker.Breaker
+	stale   *staleLists
+}
+
+func NewCircuitBreakerClient(client product.ProductServiceClient, b *breaker.Breaker, fallback StaleFallback) product.ProductServiceClient {
+	c := &breakerClient{
+		ProductServiceClient: client,
+		breaker:              b,
+	}
+
+	if fallback.Enabled {
+		c.stale = newStaleLists(fallback.TTL, fallback.MaxEntries)
+	}
+
+	return c
+}
+
+func (c *breakerClient) InsertProduct(ctx context.Context, in *product.ProductInsertRequest, opts ...grpc.CallOption) (*product.ProductInsertResponse, error) {
+	return guard(ctx, c.breaker, func() (*product.ProductInsertResponse, error) {
+		return c.ProductServiceClient.InsertProduct(ctx, in, opts...)
+	})
+}
+
+func (c *breakerClient) ListProduct(ctx context.Context, in *product.ListProductRequest, opts ...grpc.CallOption) (*product.ListProductResponse, error) {
+	res, err := guard(ctx, c.breaker, func() (*product.ListProductResponse, error) {
+		return c.ProductServiceClient.ListProduct(ctx, in, opts...)
+	})
+
+	if c.stale == nil {
+		return res, err
+	}
+
+	key, keyErr := proto.MarshalOptions{Deterministic: true}.Marshal(in)
+	if keyErr != nil {
+		return res, err
+	}
+
+	if err == nil {
+		c.stale.put(string(key), res)
+		return res, nil
+	}
+
+	if !isUnavailable(err) {
+		return nil, err
+	}
+
+	if cached, ok := c.stale.get(string(key)); ok {
+		logger.FromContext(ctx).Warn("serving stale product list",
+			slog.String("reason", err.Error()),
+		)
+		return cached, nil
+	}
+
+	return nil, err
+}
+
+func (c *breakerClient) ReduceProductQty(ctx context.Context, in *product.ReduceProductRequest, opts ...grpc.CallOption) (*product.ReduceProductResponse, error) {
+	return guard(ctx, c.breaker, func() (*product.ReduceProductResponse, error) {
+		return c.ProductServiceClient.ReduceProductQty(ctx, in, opts...)
+	})
+}
+
+func (c *breakerClient) GetProduct(ctx context.Context, in *product.GetProductRequest, opts ...grpc.CallOption) (*product.GetProductResponse, error) {
+	return guard(ctx, c.breaker, func() (*product.GetProductResponse, error) {
+		return c.ProductServiceClient.GetProduct(ctx, in, opts...)
+	})
+}
+
+func (c *breakerClient) UpdateProduct(ctx context.Context, in *product.UpdateProductRequest, opts ...grpc.CallOption) (*product.UpdateProductResponse, error) {
+	return guard(ctx, c.breaker, func() (*product.UpdateProductResponse, error) {
+		return c.ProductServiceClient.UpdateProduct(ctx, in, opts...)
+	})
+}
+
+func (c *breakerClient) DeleteProduct(ctx context.Context, in *product.DeleteProductRequest, opts ...grpc.CallOption) (*product.DeleteProductResponse, error) {
+	return guard(ctx, c.breaker, func() (*product.DeleteProductResponse, error) {
+		return c.ProductServiceClient.DeleteProduct(ctx, in, opts...)
+	})
+}
+
+func guard[T any](ctx context.Context, b *breaker.Breaker, call func() (T, error)) (T, error) {
+	if err := b.Allow(); err != nil {
+		var zero T
+		return zero, err
+	}
+
+	res, err := call()
+	b.Record(!isBackendFailure(ctx, err))
+
+	return res, err
+}
+
+// isBackendFailure reports whether err says something about the health of
+// the product service. Client mistakes and our own cancellations do not
+// count against the breaker.
+func isBackendFailure(ctx context.Context, err error) bool {
+	if err == nil {
+		return false
+	}
+	if ctx.Err() == context.Canceled {
+		return false
+	}
+
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted,
+		codes.Internal, codes.Unknown, codes.DataLoss:
+		return true
+	default:
+		return false
+	}
+}
+
+func isUnavailable(err error) bool {
+	var open *breaker.OpenError
+	if errors.As(err, &open) {
+		return true
+	}
+
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded:
+		return true
+	default:
+		return false
+	}
+}
+
+type staleEntry struct {
+	res      *product.ListProductResponse
+	storedAt time.Time
+}
+
+// staleLists keeps the last good list responses, evicting the oldest
+// entry once full.
+type staleLists struct {
+	ttl        time.Duration
+	maxEntries int
+
+	mu      sync.Mutex
+	entries map[string]staleEntry
+	order   []string
+}
+
+func newStaleLists(ttl time.Duration, maxEntries int) *staleLists {
+	if maxEntries < 1 {
+		maxEntries = 1
+	}
+
+	return &staleLists{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		entries:    make(map[string]staleEntry, maxEntries),
+	}
+}
+
+func (s *staleLists) put(key string, res *product.ListProductResponse) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.entries[key]; !ok {
+		s.order = append(s.order, key)
+	}
+	s.entries[key] = staleEntry{res: res, storedAt: time.Now()}
+
+	for len(s.order) > s.maxEntries {
+		delete(s.entries, s.order[0])
+		s.order = s.order[1:]
+	}
+}
+
+func (s *staleLists) get(key string) (*product.ListProductResponse, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if s.ttl > 0 && time.Since(entry.storedAt) > s.ttl {
+		return nil, false
+	}
+
+	return entry.res, true
+}
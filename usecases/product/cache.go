@@ -0,0 +1,167 @@
+package usecases
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/Reza1878/goesclearning/user-service/helper/cache"
+	"github.com/Reza1878/goesclearning/user-service/helper/jwt"
+	"github.com/Reza1878/goesclearning/user-service/helper/logger"
+	"github.com/Reza1878/goesclearning/user-service/proto/product"
+	"golang.org/x/sync/singleflight"
+	"google.golang.org/protobuf/proto"
+)
+
+const listGenerationKey = "product:list:generation"
+
+// cachedProductUsecase is a read-through cache in front of ProductUsecases.
+// List responses are stored under the current generation; every write
+// bumps the generation, which orphans the old entries until they expire.
+type cachedProductUsecase struct {
+	ProductUsecases
+
+	cache cache.Store
+	ttl   time.Duration
+	group singleflight.Group
+}
+
+func NewCachedProductUsecase(inner ProductUsecases, store cache.Store, ttl time.Duration) ProductUsecases {
+	return &cachedProductUsecase{
+		ProductUsecases: inner,
+		cache:           store,
+		ttl:             ttl,
+	}
+}
+
+func (c *cachedProductUsecase) ListProduct(ctx context.Context, req *product.ListProductRequest) (*product.ListProductResponse, error) {
+	normalizeListRequest(req)
+
+	key, err := c.listKey(ctx, req)
+	if err != nil {
+		logger.FromContext(ctx).Warn("product list cache unavailable", slog.Any("error", err))
+		return c.ProductUsecases.ListProduct(ctx, req)
+	}
+
+	if cached, ok := c.get(ctx, key); ok {
+		return cached, nil
+	}
+
+	res, err, _ := c.group.Do(key, func() (interface{}, error) {
+		// detached so one caller going away does not fail the others
+		callCtx := context.WithoutCancel(ctx)
+
+		res, err := c.ProductUsecases.ListProduct(callCtx, req)
+		if err != nil {
+			return nil, err
+		}
+		c.set(callCtx, key, res)
+		return res, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return proto.Clone(res.(*product.ListProductResponse)).(*product.ListProductResponse), nil
+}
+
+func (c *cachedProductUsecase) ListUserProducts(ctx context.Context, userId string, req *product.ListProductRequest) (*product.ListProductResponse, error) {
+	req.UserId = userId
+	req.IncludeStats = true
+
+	return c.ListProduct(ctx, req)
+}
+
+func (c *cachedProductUsecase) InsertProduct(ctx context.Context, req *product.ProductInsertRequest) (*product.ProductInsertResponse, error) {
+	res, err := c.ProductUsecases.InsertProduct(ctx, req)
+	if err == nil {
+		c.invalidate(ctx)
+	}
+	return res, err
+}
+
+func (c *cachedProductUsecase) UpdateProduct(ctx context.Context, claims *jwt.JWTPayload, req *product.UpdateProductRequest) (*product.Product, error) {
+	res, err := c.ProductUsecases.UpdateProduct(ctx, claims, req)
+	if err == nil {
+		c.invalidate(ctx)
+	}
+	return res, err
+}
+
+func (c *cachedProductUsecase) DeleteProduct(ctx context.Context, claims *jwt.JWTPayload, id string) (*product.DeleteProductResponse, error) {
+	res, err := c.ProductUsecases.DeleteProduct(ctx, claims, id)
+	if err == nil {
+		c.invalidate(ctx)
+	}
+	return res, err
+}
+
+func (c *cachedProductUsecase) ReduceProductQty(ctx context.Context, req *product.ReduceProductRequest) (*product.ReduceProductResponse, error) {
+	res, err := c.ProductUsecases.ReduceProductQty(ctx, req)
+	if err == nil {
+		c.invalidate(ctx)
+	}
+	return res, err
+}
+
+func (c *cachedProductUsecase) listKey(ctx context.Context, req *product.ListProductRequest) (string, error) {
+	var generation int64
+	raw, err := c.cache.Get(ctx, listGenerationKey)
+	switch {
+	case err == nil:
+		generation, err = strconv.ParseInt(string(raw), 10, 64)
+		if err != nil {
+			return "", fmt.Errorf("corrupt product list generation: %w", err)
+		}
+	case !errors.Is(err, cache.ErrMiss):
+		return "", err
+	}
+
+	raw, err = proto.MarshalOptions{Deterministic: true}.Marshal(req)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(raw)
+	return fmt.Sprintf("product:list:%d:%s", generation, hex.EncodeToString(sum[:])), nil
+}
+
+func (c *cachedProductUsecase) get(ctx context.Context, key string) (*product.ListProductResponse, bool) {
+	raw, err := c.cache.Get(ctx, key)
+	if err != nil {
+		if !errors.Is(err, cache.ErrMiss) {
+			logger.FromContext(ctx).Warn("failed to read product list cache", slog.String("key", key), slog.Any("error", err))
+		}
+		return nil, false
+	}
+
+	var res product.ListProductResponse
+	if err := proto.Unmarshal(raw, &res); err != nil {
+		logger.FromContext(ctx).Warn("corrupt product list cache entry", slog.String("key", key), slog.Any("error", err))
+		return nil, false
+	}
+
+	return &res, true
+}
+
+func (c *cachedProductUsecase) set(ctx context.Context, key string, res *product.ListProductResponse) {
+	raw, err := proto.Marshal(res)
+	if err != nil {
+		return
+	}
+
+	if err := c.cache.Set(ctx, key, raw, c.ttl); err != nil {
+		logger.FromContext(ctx).Warn("failed to write product list cache", slog.String("key", key), slog.Any("error", err))
+	}
+}
+
+func (c *cachedProductUsecase) invalidate(ctx context.Context) {
+	if _, err := c.cache.Incr(ctx, listGenerationKey); err != nil {
+		logger.FromContext(ctx).Error("failed to invalidate product list cache", slog.Any("error", err))
+	}
+}
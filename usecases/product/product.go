@@ -2,52 +2,252 @@ package usecases
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log/slog"
 	"net/http"
+	"sort"
+	"strings"
 
+	"github.com/Reza1878/goesclearning/user-service/helper/audit"
+	"github.com/Reza1878/goesclearning/user-service/helper/breaker"
+	"github.com/Reza1878/goesclearning/user-service/helper/cache"
 	"github.com/Reza1878/goesclearning/user-service/helper/fault"
+	"github.com/Reza1878/goesclearning/user-service/helper/jwt"
+	"github.com/Reza1878/goesclearning/user-service/helper/logger"
 	"github.com/Reza1878/goesclearning/user-service/proto/product"
+	"google.golang.org/protobuf/proto"
 )
 
 type productUseCase struct {
 	serverRPC product.ProductServiceClient
+	cache     cache.Store
+	audit     *audit.Log
 }
 
-func NewProductUsecase(serverRPC product.ProductServiceClient) *productUseCase {
+func NewProductUsecase(serverRPC product.ProductServiceClient, store cache.Store, auditLog *audit.Log) *productUseCase {
 	return &productUseCase{
 		serverRPC: serverRPC,
+		cache:     store,
+		audit:     auditLog,
 	}
 }
 
 var _ ProductUsecases = &productUseCase{}
 
+const (
+	defaultListLimit = 10
+	// MaxListLimit caps the page size of every product list.
+	MaxListLimit = 100
+)
+
 type ProductUsecases interface {
 	InsertProduct(ctx context.Context, req *product.ProductInsertRequest) (*product.ProductInsertResponse, error)
 	ListProduct(ctx context.Context, req *product.ListProductRequest) (*product.ListProductResponse, error)
+	ListUserProducts(ctx context.Context, userId string, req *product.ListProductRequest) (*product.ListProductResponse, error)
+	GetProduct(ctx context.Context, id string) (*product.Product, error)
+	UpdateProduct(ctx context.Context, claims *jwt.JWTPayload, req *product.UpdateProductRequest) (*product.Product, error)
+	DeleteProduct(ctx context.Context, claims *jwt.JWTPayload, id string) (*product.DeleteProductResponse, error)
+	ReduceProductQty(ctx context.Context, req *product.ReduceProductRequest) (*product.ReduceProductResponse, error)
 }
 
 func (u *productUseCase) InsertProduct(ctx context.Context, req *product.ProductInsertRequest) (*product.ProductInsertResponse, error) {
 	insertOk, err := u.serverRPC.InsertProduct(ctx, req)
 	if err != nil {
-		return nil, fault.Custom(
-			http.StatusUnprocessableEntity,
-			fault.ErrUnprocessable,
-			fmt.Sprintf("failed insert product: %v", err),
-		)
+		return nil, rpcError(err, "failed insert product")
 	}
 
 	return insertOk, nil
 }
 
 func (u *productUseCase) ListProduct(ctx context.Context, req *product.ListProductRequest) (*product.ListProductResponse, error) {
+	normalizeListRequest(req)
+
 	product, err := u.serverRPC.ListProduct(ctx, req)
 	if err != nil {
-		return nil, fault.Custom(
-			http.StatusUnprocessableEntity,
-			fault.ErrUnprocessable,
-			fmt.Sprintf("failed retrieve list product: %v", err),
-		)
+		return nil, rpcError(err, "failed retrieve list product")
 	}
 
 	return product, nil
 }
+
+// ListUserProducts lists the products owned by userId together with
+// aggregate stats over all of them.
+func (u *productUseCase) ListUserProducts(ctx context.Context, userId string, req *product.ListProductRequest) (*product.ListProductResponse, error) {
+	req.UserId = userId
+	req.IncludeStats = true
+
+	return u.ListProduct(ctx, req)
+}
+
+func (u *productUseCase) GetProduct(ctx context.Context, id string) (*product.Product, error) {
+	res, err := u.serverRPC.GetProduct(ctx, &product.GetProductRequest{Id: id})
+	if err != nil {
+		return nil, rpcError(err, fmt.Sprintf("failed retrieve product %s", id))
+	}
+
+	return res.GetProduct(), nil
+}
+
+func (u *productUseCase) UpdateProduct(ctx context.Context, claims *jwt.JWTPayload, req *product.UpdateProductRequest) (*product.Product, error) {
+	existing, err := u.authorizeOwner(ctx, claims, req.GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := u.auditAdmin(ctx, claims, audit.ActionProductUpdate, req.GetId(), existing, applyUpdate(existing, req)); err != nil {
+		return nil, err
+	}
+
+	res, err := u.serverRPC.UpdateProduct(ctx, req)
+	if err != nil {
+		u.auditFailure(ctx, claims, audit.ActionProductUpdateFailed, req.GetId())
+		return nil, rpcError(err, fmt.Sprintf("failed update product %s", req.GetId()))
+	}
+
+	return res.GetProduct(), nil
+}
+
+func (u *productUseCase) DeleteProduct(ctx context.Context, claims *jwt.JWTPayload, id string) (*product.DeleteProductResponse, error) {
+	existing, err := u.authorizeOwner(ctx, claims, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := u.auditAdmin(ctx, claims, audit.ActionProductDelete, id, existing, nil); err != nil {
+		return nil, err
+	}
+
+	res, err := u.serverRPC.DeleteProduct(ctx, &product.DeleteProductRequest{Id: id})
+	if err != nil {
+		u.auditFailure(ctx, claims, audit.ActionProductDeleteFailed, id)
+		return nil, rpcError(err, fmt.Sprintf("failed delete product %s", id))
+	}
+
+	return res, nil
+}
+
+// authorizeOwner allows the change only for the product owner or an admin
+// and returns the product as it is before the change.
+func (u *productUseCase) authorizeOwner(ctx context.Context, claims *jwt.JWTPayload, id string) (*product.Product, error) {
+	existing, err := u.GetProduct(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if claims.IsAdmin() || existing.GetUserId() == claims.UserId {
+		return existing, nil
+	}
+
+	return nil, fault.Custom(
+		http.StatusForbidden,
+		fault.ErrForbidden,
+		fmt.Sprintf("user %s does not own product %s", claims.UserId, id),
+	)
+}
+
+// auditAdmin records a change an admin is about to make. It runs before
+// the change, so an admin change never happens without its entry: when
+// the entry cannot be written the change is refused.
+func (u *productUseCase) auditAdmin(ctx context.Context, claims *jwt.JWTPayload, action, id string, before, after *product.Product) error {
+	if !claims.IsAdmin() {
+		return nil
+	}
+
+	event := audit.Event{
+		ActorId:    claims.UserId,
+		ActorRole:  claims.Role,
+		Action:     action,
+		TargetType: audit.TargetProduct,
+		TargetId:   id,
+		Before:     before,
+	}
+	// keep a nil product out of the interface, where it would not be nil
+	if after != nil {
+		event.After = after
+	}
+
+	if _, err := u.audit.Record(ctx, event); err != nil {
+		return fault.Custom(
+			http.StatusServiceUnavailable,
+			fault.ErrUnavailable,
+			fmt.Sprintf("refusing %s of product %s, audit entry not recorded: %v", action, id, err),
+		)
+	}
+	return nil
+}
+
+// auditFailure marks an audited change that the product service did not
+// apply. The request fails anyway, so an error here is only logged.
+func (u *productUseCase) auditFailure(ctx context.Context, claims *jwt.JWTPayload, action, id string) {
+	if !claims.IsAdmin() {
+		return
+	}
+
+	_, err := u.audit.Record(ctx, audit.Event{
+		ActorId:    claims.UserId,
+		ActorRole:  claims.Role,
+		Action:     action,
+		TargetType: audit.TargetProduct,
+		TargetId:   id,
+	})
+	if err != nil {
+		logger.FromContext(ctx).Error("failed to record audit entry",
+			slog.String("action", action),
+			slog.String("product_id", id),
+			slog.Any("error", err),
+		)
+	}
+}
+
+// applyUpdate returns existing as it will look after req, for the audit
+// entry written before the update is sent.
+func applyUpdate(existing *product.Product, req *product.UpdateProductRequest) *product.Product {
+	after := proto.Clone(existing).(*product.Product)
+	if req.Name != nil {
+		after.Name = req.GetName()
+	}
+	if req.Description != nil {
+		after.Description = req.GetDescription()
+	}
+	if req.Qty != nil {
+		after.Qty = req.GetQty()
+	}
+	if req.Price != nil {
+		after.Price = req.GetPrice()
+	}
+	if req.PriceMoney != nil {
+		after.PriceMoney = req.GetPriceMoney()
+	}
+	return after
+}
+
+// normalizeListRequest applies the paging defaults and caps so that
+// equivalent requests look the same to the backend and to the cache.
+func normalizeListRequest(req *product.ListProductRequest) {
+	if req.GetPage() == 0 {
+		req.Page = 1
+	}
+	if req.GetLimit() == 0 {
+		req.Limit = defaultListLimit
+	}
+	if req.GetLimit() > MaxListLimit {
+		req.Limit = MaxListLimit
+	}
+
+	req.Q = strings.TrimSpace(req.GetQ())
+	sort.Strings(req.ProductIds)
+}
+
+func rpcError(err error, msg string) error {
+	var open *breaker.OpenError
+	if errors.As(err, &open) {
+		return fault.Custom(
+			http.StatusServiceUnavailable,
+			fault.ErrUnavailable,
+			fmt.Sprintf("%s: %v", msg, err),
+		).WithRetryAfter(open.RetryAfter)
+	}
+
+	return fault.FromGRPC(err, msg)
+}
@@ -0,0 +1,504 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Reza1878/goesclearning/user-service/helper/audit"
+	"github.com/Reza1878/goesclearning/user-service/helper/breaker"
+	"github.com/Reza1878/goesclearning/user-service/helper/cache"
+	"github.com/Reza1878/goesclearning/user-service/helper/fault"
+	"github.com/Reza1878/goesclearning/user-service/helper/jwt"
+	"github.com/Reza1878/goesclearning/user-service/model"
+	"github.com/Reza1878/goesclearning/user-service/proto/product"
+	"github.com/Reza1878/goesclearning/user-service/proto/product/fake"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	owner    = &jwt.JWTPayload{UserId: "owner", Role: model.RoleUser}
+	stranger = &jwt.JWTPayload{UserId: "stranger", Role: model.RoleUser}
+	admin    = &jwt.JWTPayload{UserId: "admin", Role: model.RoleAdmin}
+)
+
+type contract struct {
+	usecase *productUseCase
+	audit   audit.Store
+}
+
+// newContract runs the usecase against srv through a real gRPC client, so
+// status codes and details cross the wire as they do in production.
+func newContract(t *testing.T, srv product.ProductServiceServer) *contract {
+	t.Helper()
+
+	conn, stop, err := fake.Dial(srv)
+	if err != nil {
+		t.Fatalf("dial fake product service: %v", err)
+	}
+	t.Cleanup(stop)
+
+	store := audit.NewMemory()
+	return &contract{
+		usecase: NewProductUsecase(product.NewProductServiceClient(conn), cache.NewMemory(100), audit.New(store)),
+		audit:   store,
+	}
+}
+
+func (c *contract) insert(t *testing.T, qty uint32) string {
+	t.Helper()
+
+	res, err := c.usecase.InsertProduct(context.Background(), &product.ProductInsertRequest{
+		Name:        "Keyboard",
+		Description: "mechanical",
+		Qty:         qty,
+		UserId:      owner.UserId,
+		PriceMoney:  &product.Money{MinorUnits: 1999, Currency: "USD"},
+	})
+	if err != nil {
+		t.Fatalf("InsertProduct: %v", err)
+	}
+
+	// the message is "product <id> created"
+	fields := strings.Fields(res.GetMsg())
+	if len(fields) != 3 {
+		t.Fatalf("unexpected insert message %q", res.GetMsg())
+	}
+	return fields[1]
+}
+
+func (c *contract) qty(t *testing.T, id string) uint32 {
+	t.Helper()
+
+	p, err := c.usecase.GetProduct(context.Background(), id)
+	if err != nil {
+		t.Fatalf("GetProduct: %v", err)
+	}
+	return p.GetQty()
+}
+
+func (c *contract) auditEntries(t *testing.T) []audit.Entry {
+	t.Helper()
+
+	entries, _, err := c.audit.List(context.Background(), audit.Filter{Page: 1, Limit: 100})
+	if err != nil {
+		t.Fatalf("list audit entries: %v", err)
+	}
+	return entries
+}
+
+func errorCode(err error) fault.ErrorCode {
+	var detailed *fault.DetailedError
+	if errors.As(err, &detailed) {
+		return detailed.Code
+	}
+	return ""
+}
+
+func TestProductInsertGetList(t *testing.T) {
+	c := newContract(t, fake.NewServer())
+	ctx := context.Background()
+
+	id := c.insert(t, 5)
+
+	p, err := c.usecase.GetProduct(ctx, id)
+	if err != nil {
+		t.Fatalf("GetProduct: %v", err)
+	}
+	if p.GetPriceMoney().GetMinorUnits() != 1999 || p.GetPriceMoney().GetCurrency() != "USD" {
+		t.Fatalf("price = %v, want 1999 USD", p.GetPriceMoney())
+	}
+
+	res, err := c.usecase.ListUserProducts(ctx, owner.UserId, &product.ListProductRequest{})
+	if err != nil {
+		t.Fatalf("ListUserProducts: %v", err)
+	}
+	if len(res.GetItems()) != 1 || res.GetStats().GetTotalQty() != 5 {
+		t.Fatalf("list = %v, want one product with 5 in stock", res)
+	}
+	if res.GetMeta().GetLimit() != defaultListLimit || res.GetMeta().GetCurrentPage() != 1 {
+		t.Fatalf("meta = %v, want the paging defaults", res.GetMeta())
+	}
+
+	// (page-1)*limit is a multiple of 2^32 here
+	res, err = c.usecase.ListUserProducts(ctx, owner.UserId, &product.ListProductRequest{Page: 1<<31 + 1})
+	if err != nil {
+		t.Fatalf("ListUserProducts: %v", err)
+	}
+	if len(res.GetItems()) != 0 {
+		t.Fatalf("page far past the end = %v, want no items", res.GetItems())
+	}
+
+	if _, err := c.usecase.GetProduct(ctx, "missing"); errorCode(err) != fault.ErrNotFound {
+		t.Fatalf("GetProduct(missing): got %v, want not found", err)
+	}
+}
+
+func TestProductOwnership(t *testing.T) {
+	tests := []struct {
+		name      string
+		claims    *jwt.JWTPayload
+		wantErr   fault.ErrorCode
+		wantAudit int
+	}{
+		{"owner", owner, "", 0},
+		{"stranger", stranger, fault.ErrForbidden, 0},
+		{"admin", admin, "", 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name+" updates", func(t *testing.T) {
+			c := newContract(t, fake.NewServer())
+			id := c.insert(t, 5)
+
+			qty := uint32(7)
+			res, err := c.usecase.UpdateProduct(context.Background(), tt.claims, &product.UpdateProductRequest{Id: id, Qty: &qty})
+			if code := errorCode(err); code != tt.wantErr {
+				t.Fatalf("UpdateProduct: got %v, want %q", err, tt.wantErr)
+			}
+
+			want := uint32(5)
+			if tt.wantErr == "" {
+				want = 7
+				if res.GetQty() != 7 {
+					t.Fatalf("updated qty = %d, want 7", res.GetQty())
+				}
+			}
+			if got := c.qty(t, id); got != want {
+				t.Fatalf("stored qty = %d, want %d", got, want)
+			}
+			if got := len(c.auditEntries(t)); got != tt.wantAudit {
+				t.Fatalf("audit entries = %d, want %d", got, tt.wantAudit)
+			}
+		})
+
+		t.Run(tt.name+" deletes", func(t *testing.T) {
+			c := newContract(t, fake.NewServer())
+			id := c.insert(t, 5)
+
+			_, err := c.usecase.DeleteProduct(context.Background(), tt.claims, id)
+			if code := errorCode(err); code != tt.wantErr {
+				t.Fatalf("DeleteProduct: got %v, want %q", err, tt.wantErr)
+			}
+
+			_, err = c.usecase.GetProduct(context.Background(), id)
+			if deleted := errorCode(err) == fault.ErrNotFound; deleted != (tt.wantErr == "") {
+				t.Fatalf("GetProduct after delete: %v", err)
+			}
+
+			entries := c.auditEntries(t)
+			if len(entries) != tt.wantAudit {
+				t.Fatalf("audit entries = %d, want %d", len(entries), tt.wantAudit)
+			}
+			if tt.wantAudit > 0 && (entries[0].Action != audit.ActionProductDelete || entries[0].TargetId != id) {
+				t.Fatalf("audit entry = %+v, want a delete of %s", entries[0], id)
+			}
+		})
+	}
+
+	t.Run("missing product", func(t *testing.T) {
+		c := newContract(t, fake.NewServer())
+		if _, err := c.usecase.DeleteProduct(context.Background(), owner, "missing"); errorCode(err) != fault.ErrNotFound {
+			t.Fatalf("DeleteProduct(missing): got %v, want not found", err)
+		}
+	})
+}
+
+func TestProductAdminAudit(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("entry describes the change", func(t *testing.T) {
+		c := newContract(t, fake.NewServer())
+		id := c.insert(t, 5)
+
+		qty := uint32(7)
+		if _, err := c.usecase.UpdateProduct(ctx, admin, &product.UpdateProductRequest{Id: id, Qty: &qty}); err != nil {
+			t.Fatalf("UpdateProduct: %v", err)
+		}
+
+		entries := c.auditEntries(t)
+		if len(entries) != 1 || entries[0].Action != audit.ActionProductUpdate {
+			t.Fatalf("audit entries = %+v, want one update", entries)
+		}
+		if !strings.Contains(string(entries[0].Changes), `"qty":{"before":5,"after":7}`) {
+			t.Fatalf("changes = %s, want qty 5 to 7", entries[0].Changes)
+		}
+	})
+
+	t.Run("unrecorded change is refused", func(t *testing.T) {
+		c := newContract(t, fake.NewServer())
+		id := c.insert(t, 5)
+		c.usecase.audit = audit.New(brokenStore{Store: c.audit})
+
+		qty := uint32(7)
+		if _, err := c.usecase.UpdateProduct(ctx, admin, &product.UpdateProductRequest{Id: id, Qty: &qty}); errorCode(err) != fault.ErrUnavailable {
+			t.Fatalf("UpdateProduct: got %v, want %s", err, fault.ErrUnavailable)
+		}
+		if _, err := c.usecase.DeleteProduct(ctx, admin, id); errorCode(err) != fault.ErrUnavailable {
+			t.Fatalf("DeleteProduct: got %v, want %s", err, fault.ErrUnavailable)
+		}
+		if got := c.qty(t, id); got != 5 {
+			t.Fatalf("stored qty = %d, want 5", got)
+		}
+
+		// owners are not audited, so they are not blocked
+		if _, err := c.usecase.UpdateProduct(ctx, owner, &product.UpdateProductRequest{Id: id, Qty: &qty}); err != nil {
+			t.Fatalf("owner UpdateProduct: %v", err)
+		}
+	})
+
+	t.Run("failed change is marked", func(t *testing.T) {
+		srv := &failingMutations{Server: fake.NewServer()}
+		c := newContract(t, srv)
+		id := c.insert(t, 5)
+		srv.fail = true
+
+		qty := uint32(7)
+		if _, err := c.usecase.UpdateProduct(ctx, admin, &product.UpdateProductRequest{Id: id, Qty: &qty}); errorCode(err) != fault.ErrUnavailable {
+			t.Fatalf("UpdateProduct: got %v, want %s", err, fault.ErrUnavailable)
+		}
+		if _, err := c.usecase.DeleteProduct(ctx, admin, id); errorCode(err) != fault.ErrUnavailable {
+			t.Fatalf("DeleteProduct: got %v, want %s", err, fault.ErrUnavailable)
+		}
+
+		// newest first
+		var actions []string
+		for _, e := range c.auditEntries(t) {
+			actions = append(actions, e.Action)
+		}
+		want := []string{audit.ActionProductDeleteFailed, audit.ActionProductDelete, audit.ActionProductUpdateFailed, audit.ActionProductUpdate}
+		if strings.Join(actions, " ") != strings.Join(want, " ") {
+			t.Fatalf("audit actions = %v, want %v", actions, want)
+		}
+	})
+}
+
+func reduceRequest(key string, items map[string]uint32) *product.ReduceProductRequest {
+	req := &product.ReduceProductRequest{UserId: owner.UserId, IdempotencyKey: key}
+	for id, qty := range items {
+		req.Items = append(req.Items, &product.ReduceProductItem{ProductId: id, Qty: qty})
+	}
+	return req
+}
+
+func TestReduceProductQty(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("replays a repeated key", func(t *testing.T) {
+		c := newContract(t, fake.NewServer())
+		id := c.insert(t, 5)
+
+		first, err := c.usecase.ReduceProductQty(ctx, reduceRequest("k1", map[string]uint32{id: 2}))
+		if err != nil {
+			t.Fatalf("first reduce: %v", err)
+		}
+		second, err := c.usecase.ReduceProductQty(ctx, reduceRequest("k1", map[string]uint32{id: 2}))
+		if err != nil {
+			t.Fatalf("replayed reduce: %v", err)
+		}
+
+		if first.GetMessage() != second.GetMessage() {
+			t.Fatalf("replay message %q, want %q", second.GetMessage(), first.GetMessage())
+		}
+		if got := c.qty(t, id); got != 3 {
+			t.Fatalf("qty = %d, want 3", got)
+		}
+	})
+
+	t.Run("rejects a reused key with another payload", func(t *testing.T) {
+		c := newContract(t, fake.NewServer())
+		id := c.insert(t, 5)
+
+		if _, err := c.usecase.ReduceProductQty(ctx, reduceRequest("k1", map[string]uint32{id: 2})); err != nil {
+			t.Fatalf("first reduce: %v", err)
+		}
+		_, err := c.usecase.ReduceProductQty(ctx, reduceRequest("k1", map[string]uint32{id: 3}))
+		if errorCode(err) != fault.ErrIdempotencyKeyReused {
+			t.Fatalf("got %v, want %s", err, fault.ErrIdempotencyKeyReused)
+		}
+	})
+
+	t.Run("releases the key after insufficient stock", func(t *testing.T) {
+		c := newContract(t, fake.NewServer())
+		id := c.insert(t, 1)
+
+		_, err := c.usecase.ReduceProductQty(ctx, reduceRequest("k1", map[string]uint32{id: 2}))
+		var detailed *fault.DetailedError
+		if !errors.As(err, &detailed) || detailed.Code != fault.ErrInsufficientStock {
+			t.Fatalf("got %v, want %s", err, fault.ErrInsufficientStock)
+		}
+		if len(detailed.Details) != 1 {
+			t.Fatalf("details = %+v, want the product id", detailed.Details)
+		}
+
+		qty := uint32(5)
+		if _, err := c.usecase.UpdateProduct(ctx, owner, &product.UpdateProductRequest{Id: id, Qty: &qty}); err != nil {
+			t.Fatalf("restock: %v", err)
+		}
+		if _, err := c.usecase.ReduceProductQty(ctx, reduceRequest("k1", map[string]uint32{id: 2})); err != nil {
+			t.Fatalf("retry with the same key: %v", err)
+		}
+		if got := c.qty(t, id); got != 3 {
+			t.Fatalf("qty = %d, want 3", got)
+		}
+	})
+
+	t.Run("keeps the key after an ambiguous failure", func(t *testing.T) {
+		srv := &flakyReduce{Server: fake.NewServer(), fail: true}
+		c := newContract(t, srv)
+		id := c.insert(t, 5)
+
+		_, err := c.usecase.ReduceProductQty(ctx, reduceRequest("k1", map[string]uint32{id: 2}))
+		if errorCode(err) != fault.ErrUnavailable {
+			t.Fatalf("got %v, want %s", err, fault.ErrUnavailable)
+		}
+
+		// the reduce may have happened, so a retry must not run it again
+		srv.fail = false
+		_, err = c.usecase.ReduceProductQty(ctx, reduceRequest("k1", map[string]uint32{id: 2}))
+		if errorCode(err) != fault.ErrConflict {
+			t.Fatalf("retry: got %v, want %s while the key is pending", err, fault.ErrConflict)
+		}
+		if got := c.qty(t, id); got != 5 {
+			t.Fatalf("qty = %d, want 5", got)
+		}
+	})
+
+	t.Run("releases the key when the breaker is open", func(t *testing.T) {
+		c := newContract(t, fake.NewServer())
+		id := c.insert(t, 5)
+		client := &openBreaker{ProductServiceClient: c.usecase.serverRPC, open: true}
+		c.usecase.serverRPC = client
+
+		_, err := c.usecase.ReduceProductQty(ctx, reduceRequest("k1", map[string]uint32{id: 2}))
+		if errorCode(err) != fault.ErrUnavailable {
+			t.Fatalf("got %v, want %s", err, fault.ErrUnavailable)
+		}
+
+		// the call never left the process, so the retry runs it
+		client.open = false
+		if _, err := c.usecase.ReduceProductQty(ctx, reduceRequest("k1", map[string]uint32{id: 2})); err != nil {
+			t.Fatalf("retry with the same key: %v", err)
+		}
+		if got := c.qty(t, id); got != 3 {
+			t.Fatalf("qty = %d, want 3", got)
+		}
+	})
+
+	t.Run("claims a key whose record expired", func(t *testing.T) {
+		c := newContract(t, fake.NewServer())
+		id := c.insert(t, 5)
+		c.usecase.cache = &expiringStore{Store: c.usecase.cache, expiring: 1}
+
+		if _, err := c.usecase.ReduceProductQty(ctx, reduceRequest("k1", map[string]uint32{id: 2})); err != nil {
+			t.Fatalf("reduce: %v", err)
+		}
+		if got := c.qty(t, id); got != 3 {
+			t.Fatalf("qty = %d, want 3", got)
+		}
+
+		// a record that keeps expiring is given up on
+		c.usecase.cache = &expiringStore{Store: c.usecase.cache, expiring: idempotencyClaimAttempts}
+		if _, err := c.usecase.ReduceProductQty(ctx, reduceRequest("k2", map[string]uint32{id: 1})); errorCode(err) != fault.ErrConflict {
+			t.Fatalf("got %v, want %s", err, fault.ErrConflict)
+		}
+	})
+
+	t.Run("validates items before calling the service", func(t *testing.T) {
+		c := newContract(t, fake.NewServer())
+		req := &product.ReduceProductRequest{UserId: owner.UserId, IdempotencyKey: "k1", Items: []*product.ReduceProductItem{
+			{ProductId: "a", Qty: 1},
+			{ProductId: "a", Qty: 2},
+		}}
+		if _, err := c.usecase.ReduceProductQty(ctx, req); errorCode(err) != fault.ErrBadRequest {
+			t.Fatalf("got %v, want %s", err, fault.ErrBadRequest)
+		}
+	})
+}
+
+// flakyReduce fails stock reductions as if the product service was
+// unreachable.
+type flakyReduce struct {
+	*fake.Server
+	fail bool
+}
+
+func (s *flakyReduce) ReduceProductQty(ctx context.Context, req *product.ReduceProductRequest) (*product.ReduceProductResponse, error) {
+	if s.fail {
+		return nil, status.Error(codes.Unavailable, "product service unreachable")
+	}
+	return s.Server.ReduceProductQty(ctx, req)
+}
+
+// failingMutations fails updates and deletes as if the product service
+// was unreachable.
+type failingMutations struct {
+	*fake.Server
+	fail bool
+}
+
+func (s *failingMutations) UpdateProduct(ctx context.Context, req *product.UpdateProductRequest) (*product.UpdateProductResponse, error) {
+	if s.fail {
+		return nil, status.Error(codes.Unavailable, "product service unreachable")
+	}
+	return s.Server.UpdateProduct(ctx, req)
+}
+
+func (s *failingMutations) DeleteProduct(ctx context.Context, req *product.DeleteProductRequest) (*product.DeleteProductResponse, error) {
+	if s.fail {
+		return nil, status.Error(codes.Unavailable, "product service unreachable")
+	}
+	return s.Server.DeleteProduct(ctx, req)
+}
+
+// brokenStore cannot append, like an audit table that is unreachable.
+type brokenStore struct {
+	audit.Store
+}
+
+func (brokenStore) Append(context.Context, audit.Entry) (*audit.Entry, error) {
+	return nil, errors.New("audit store unreachable")
+}
+
+// openBreaker fails stock reductions the way the circuit breaker does
+// while it is open, without sending them.
+type openBreaker struct {
+	product.ProductServiceClient
+	open bool
+}
+
+func (c *openBreaker) ReduceProductQty(ctx context.Context, in *product.ReduceProductRequest, opts ...grpc.CallOption) (*product.ReduceProductResponse, error) {
+	if c.open {
+		return nil, &breaker.OpenError{Name: "product", RetryAfter: time.Second}
+	}
+	return c.ProductServiceClient.ReduceProductQty(ctx, in, opts...)
+}
+
+// expiringStore reports the first expiring SetNX calls as taken and then
+// misses on Get, as when a held key expires between the two.
+type expiringStore struct {
+	cache.Store
+	expiring int
+	missed   bool
+}
+
+func (s *expiringStore) SetNX(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error) {
+	if s.expiring > 0 {
+		s.expiring--
+		s.missed = true
+		return false, nil
+	}
+	return s.Store.SetNX(ctx, key, value, ttl)
+}
+
+func (s *expiringStore) Get(ctx context.Context, key string) ([]byte, error) {
+	if s.missed {
+		s.missed = false
+		return nil, cache.ErrMiss
+	}
+	return s.Store.Get(ctx, key)
+}
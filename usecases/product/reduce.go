@@ -0,0 +1,252 @@
+package usecases
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/Reza1878/goesclearning/user-service/helper/breaker"
+	"github.com/Reza1878/goesclearning/user-service/helper/cache"
+	"github.com/Reza1878/goesclearning/user-service/helper/fault"
+	"github.com/Reza1878/goesclearning/user-service/helper/logger"
+	"github.com/Reza1878/goesclearning/user-service/proto/product"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	idempotencyPendingTTL = time.Minute
+	idempotencyResultTTL  = 24 * time.Hour
+	// idempotencyClaimAttempts bounds how often a key whose record expired
+	// between SetNX and Get is claimed again.
+	idempotencyClaimAttempts = 3
+
+	// violationInsufficientStock is the PreconditionFailure type the
+	// product service uses for items without enough stock.
+	violationInsufficientStock = "INSUFFICIENT_STOCK"
+)
+
+type idempotencyRecord struct {
+	Fingerprint string `json:"fingerprint"`
+	Done        bool   `json:"done"`
+	Message     string `json:"message,omitempty"`
+}
+
+// ReduceProductQty decrements stock once per idempotency key. A retry with
+// the same key and payload gets the stored result back; a retry while the
+// first call is still running, or with a different payload, is rejected.
+func (u *productUseCase) ReduceProductQty(ctx context.Context, req *product.ReduceProductRequest) (*product.ReduceProductResponse, error) {
+	if err := validateReduceItems(req.GetItems()); err != nil {
+		return nil, err
+	}
+
+	fingerprint, err := reduceFingerprint(req)
+	if err != nil {
+		return nil, err
+	}
+
+	key := fmt.Sprintf("idempotency:reduce:%s:%s", req.GetUserId(), req.GetIdempotencyKey())
+	pending, _ := json.Marshal(idempotencyRecord{Fingerprint: fingerprint})
+
+	for attempt := 1; ; attempt++ {
+		acquired, err := u.cache.SetNX(ctx, key, pending, idempotencyPendingTTL)
+		if err != nil {
+			return nil, err
+		}
+		if acquired {
+			break
+		}
+
+		res, err := replayReduce(ctx, u.cache, key, fingerprint)
+		if !errors.Is(err, cache.ErrMiss) {
+			return res, err
+		}
+		// the record expired between SetNX and Get, so the key is free
+		if attempt == idempotencyClaimAttempts {
+			return nil, fault.Custom(
+				http.StatusConflict,
+				fault.ErrConflict,
+				fmt.Sprintf("idempotency record %s expired while it was read", key),
+			)
+		}
+	}
+
+	res, err := u.serverRPC.ReduceProductQty(ctx, req)
+	if err != nil {
+		// Only a call that was rejected, or never sent, frees the key for
+		// an immediate retry. After a timeout or an unavailable backend the
+		// stock may already be reduced, so the pending record stays until
+		// it expires and the backend dedupes the forwarded key on the next
+		// attempt.
+		if reduceNotApplied(err) {
+			_ = u.cache.Delete(ctx, key)
+		}
+
+		if stockErr := insufficientStock(err); stockErr != nil {
+			return nil, stockErr
+		}
+		return nil, rpcError(err, "failed reduce product qty")
+	}
+
+	done := idempotencyRecord{Fingerprint: fingerprint, Done: true, Message: res.GetMessage()}
+	if err := cache.SetJSON(ctx, u.cache, key, done, idempotencyResultTTL); err != nil {
+		// the stock is already reduced; failing here would invite a retry
+		logger.FromContext(ctx).Error("failed to store idempotency result",
+			slog.String("key", key),
+			slog.Any("error", err),
+		)
+	}
+
+	return res, nil
+}
+
+// reduceNotApplied reports whether a failed reduce certainly left the
+// stock alone: the breaker refused it, it failed before reaching the
+// wire, or the product service rejected it.
+func reduceNotApplied(err error) bool {
+	var open *breaker.OpenError
+	if errors.As(err, &open) {
+		return true
+	}
+	if _, ok := status.FromError(err); !ok {
+		return true
+	}
+
+	switch status.Code(err) {
+	case codes.InvalidArgument, codes.NotFound, codes.FailedPrecondition:
+		return true
+	}
+	return false
+}
+
+func replayReduce(ctx context.Context, store cache.Store, key, fingerprint string) (*product.ReduceProductResponse, error) {
+	raw, err := store.Get(ctx, key)
+	if errors.Is(err, cache.ErrMiss) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fault.Custom(
+			http.StatusConflict,
+			fault.ErrConflict,
+			fmt.Sprintf("failed to read idempotency record %s: %v", key, err),
+		)
+	}
+
+	var record idempotencyRecord
+	if err := json.Unmarshal(raw, &record); err != nil {
+		return nil, fault.Custom(
+			http.StatusInternalServerError,
+			fault.ErrInternalServer,
+			fmt.Sprintf("corrupt idempotency record %s: %v", key, err),
+		)
+	}
+
+	if record.Fingerprint != fingerprint {
+		return nil, fault.Custom(
+			http.StatusUnprocessableEntity,
+			fault.ErrIdempotencyKeyReused,
+			fmt.Sprintf("idempotency key %s reused with a different payload", key),
+		)
+	}
+
+	if !record.Done {
+		return nil, fault.Custom(
+			http.StatusConflict,
+			fault.ErrConflict,
+			fmt.Sprintf("request with idempotency key %s is still in progress", key),
+		)
+	}
+
+	return &product.ReduceProductResponse{Message: record.Message}, nil
+}
+
+func validateReduceItems(items []*product.ReduceProductItem) error {
+	seen := make(map[string]bool, len(items))
+	for i, item := range items {
+		if item.GetQty() == 0 {
+			return fault.Custom(
+				http.StatusBadRequest,
+				fault.ErrBadRequest,
+				fmt.Sprintf("item %d has zero qty", i),
+			).WithDetails(fault.FieldError(fmt.Sprintf("items[%d].qty", i), "gt", "0"))
+		}
+
+		if seen[item.GetProductId()] {
+			return fault.Custom(
+				http.StatusBadRequest,
+				fault.ErrBadRequest,
+				fmt.Sprintf("product %s listed more than once", item.GetProductId()),
+			).WithDetails(fault.FieldError(fmt.Sprintf("items[%d].product_id", i), "unique", ""))
+		}
+		seen[item.GetProductId()] = true
+	}
+
+	return nil
+}
+
+// reduceFingerprint hashes the items independent of their order.
+func reduceFingerprint(req *product.ReduceProductRequest) (string, error) {
+	items := make([]*product.ReduceProductItem, len(req.GetItems()))
+	copy(items, req.GetItems())
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].GetProductId() < items[j].GetProductId()
+	})
+
+	raw, err := proto.MarshalOptions{Deterministic: true}.Marshal(&product.ReduceProductRequest{Items: items})
+	if err != nil {
+		return "", fault.Custom(
+			http.StatusInternalServerError,
+			fault.ErrInternalServer,
+			fmt.Sprintf("failed to fingerprint reduce request: %v", err),
+		)
+	}
+
+	sum := sha256.Sum256(raw)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// insufficientStock turns a FailedPrecondition carrying stock violations
+// into a 409 that lists the offending product IDs.
+func insufficientStock(err error) error {
+	st, ok := status.FromError(err)
+	if !ok || st.Code() != codes.FailedPrecondition {
+		return nil
+	}
+
+	var productIDs []string
+	for _, detail := range st.Details() {
+		failure, ok := detail.(*errdetails.PreconditionFailure)
+		if !ok {
+			continue
+		}
+		for _, violation := range failure.GetViolations() {
+			if violation.GetType() == violationInsufficientStock {
+				productIDs = append(productIDs, violation.GetSubject())
+			}
+		}
+	}
+
+	if len(productIDs) == 0 {
+		return nil
+	}
+
+	stockErr := fault.Custom(
+		http.StatusConflict,
+		fault.ErrInsufficientStock,
+		fmt.Sprintf("insufficient stock for products %v: %v", productIDs, err),
+	)
+	for _, id := range productIDs {
+		stockErr.WithDetails(fault.FieldError("product_id", "insufficient_stock", id))
+	}
+
+	return stockErr
+}
@@ -0,0 +1,121 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/Reza1878/goesclearning/user-service/helper/fault"
+	"github.com/Reza1878/goesclearning/user-service/helper/jwt"
+	"github.com/Reza1878/goesclearning/user-service/model"
+	"github.com/google/uuid"
+)
+
+// pruneBatchSize bounds each retention delete so one run does not hold
+// locks on a large table for long.
+const pruneBatchSize = 10000
+
+// recordEvent stores a login event. Failing to record never fails the
+// authentication itself.
+func (u *userUsecase) recordEvent(event model.LoginEvent, client model.ClientInfo) {
+	event.IP = client.IP
+	event.UserAgent = client.UserAgent
+
+	if err := u.events.InsertLoginEvent(event); err != nil {
+		slog.Warn("failed to record login event",
+			slog.String("event", event.Event),
+			slog.Any("error", err),
+		)
+	}
+}
+
+// ListSecurityEvents is the caller's own authentication history.
+func (u *userUsecase) ListSecurityEvents(claims *jwt.JWTPayload, query model.LoginEventQuery) (*model.LoginEventListRes, error) {
+	userId, err := uuid.Parse(claims.UserId)
+	if err != nil {
+		return nil, fault.Custom(
+			http.StatusUnauthorized,
+			fault.ErrUnauthorized,
+			fmt.Sprintf("invalid user id in token: %q", claims.UserId),
+		)
+	}
+
+	return u.listEvents(userId, query)
+}
+
+// ListAllSecurityEvents is the admin feed across users, optionally
+// narrowed to query.UserId.
+func (u *userUsecase) ListAllSecurityEvents(query model.LoginEventQuery) (*model.LoginEventListRes, error) {
+	userId := uuid.Nil
+	if query.UserId != "" {
+		parsed, err := uuid.Parse(query.UserId)
+		if err != nil {
+			return nil, fault.Custom(
+				http.StatusBadRequest,
+				fault.ErrBadRequest,
+				fmt.Sprintf("invalid user_id filter: %q", query.UserId),
+			).WithDetails(fault.FieldError("user_id", "uuid", ""))
+		}
+		userId = parsed
+	}
+
+	return u.listEvents(userId, query)
+}
+
+func (u *userUsecase) listEvents(userId uuid.UUID, query model.LoginEventQuery) (*model.LoginEventListRes, error) {
+	events, total, err := u.events.ListLoginEvents(userId, query)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.LoginEventListRes{
+		Items: events,
+		Meta: model.ListMeta{
+			TotalData:   total,
+			TotalPage:   (total + query.Limit - 1) / query.Limit,
+			CurrentPage: query.Page,
+			Limit:       query.Limit,
+		},
+	}, nil
+}
+
+// RunEventRetention deletes login events older than retention every
+// interval until ctx is done.
+func (u *userUsecase) RunEventRetention(ctx context.Context, retention, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		u.pruneEvents(time.Now().Add(-retention))
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (u *userUsecase) pruneEvents(before time.Time) {
+	var total int64
+	for {
+		n, err := u.events.PruneLoginEvents(before, pruneBatchSize)
+		if err != nil {
+			slog.Warn("failed to prune login events", slog.Any("error", err))
+			return
+		}
+		total += n
+		if n < pruneBatchSize {
+			break
+		}
+	}
+
+	if total > 0 {
+		slog.Info("pruned login events",
+			slog.Int64("deleted", total),
+			slog.Time("before", before),
+		)
+	}
+}
@@ -0,0 +1,253 @@
+package usecases
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/Reza1878/goesclearning/user-service/helper/fault"
+	"github.com/Reza1878/goesclearning/user-service/helper/jwt"
+	"github.com/Reza1878/goesclearning/user-service/model"
+	"github.com/google/uuid"
+)
+
+// sessionTouchInterval limits how often an authenticated request updates
+// the session's last_used_at.
+const sessionTouchInterval = time.Minute
+
+// startSession records a new session for the login, and the login event
+// for it, and issues tokens bound to the session.
+func (u *userUsecase) startSession(user *model.User, event, deviceName string, client model.ClientInfo) (*model.LoginResponse, error) {
+	expiresAt := time.Now().Add(jwt.RefreshTokenExpiry())
+	session := &model.Session{
+		UserId:         user.Id,
+		FamilyId:       uuid.New(),
+		RefreshTokenId: uuid.New(),
+		DeviceName:     deviceName,
+		UserAgent:      client.UserAgent,
+		IP:             client.IP,
+		ExpiresAt:      &expiresAt,
+	}
+
+	id, err := u.sessions.CreateSession(*session)
+	if err != nil {
+		return nil, err
+	}
+	session.Id = *id
+
+	u.recordEvent(model.LoginEvent{
+		UserId:     &user.Id,
+		Identifier: user.Email,
+		Event:      event,
+		SessionId:  &session.Id,
+	}, client)
+
+	return issueTokens(user, session)
+}
+
+// RefreshSession exchanges the latest refresh token of a session for a new
+// token pair and rotates the session to the new refresh token. A refresh
+// token that was already rotated out means it leaked or was replayed, so
+// the whole session is revoked.
+func (u *userUsecase) RefreshSession(refreshToken string, client model.ClientInfo) (*model.LoginResponse, error) {
+	claims, err := jwt.GetClaims(refreshToken)
+	if err != nil {
+		return nil, err
+	}
+	if claims.Type != jwt.TypeRefresh {
+		return nil, fault.Custom(
+			http.StatusUnauthorized,
+			fault.ErrUnauthorized,
+			fmt.Sprintf("token of type %q is not a refresh token", claims.Type),
+		)
+	}
+
+	sessionId, errSession := uuid.Parse(claims.SessionId)
+	tokenId, errToken := uuid.Parse(claims.ID)
+	userId, errUser := uuid.Parse(claims.UserId)
+	if errSession != nil || errToken != nil || errUser != nil {
+		return nil, fault.Custom(
+			http.StatusUnauthorized,
+			fault.ErrUnauthorized,
+			fmt.Sprintf("malformed refresh token for session %q", claims.SessionId),
+		)
+	}
+
+	session, err := u.liveSession(sessionId, claims.UserId)
+	if err != nil {
+		return nil, err
+	}
+	if session.FamilyId.String() != claims.FamilyId {
+		return nil, fault.Custom(
+			http.StatusUnauthorized,
+			fault.ErrUnauthorized,
+			fmt.Sprintf("refresh token family does not match session %s", sessionId),
+		)
+	}
+	if session.RefreshTokenId != tokenId {
+		return nil, u.refreshReused(userId, sessionId, claims.Email, client)
+	}
+
+	user, err := u.user.GetUserDetail(model.GetUserDetailRequest{UserId: userId})
+	if err != nil {
+		return nil, err
+	}
+
+	expiresAt := time.Now().Add(jwt.RefreshTokenExpiry())
+	next := uuid.New()
+	rotated, err := u.sessions.RotateRefreshToken(sessionId, tokenId, next, expiresAt)
+	if err != nil {
+		return nil, err
+	}
+	if !rotated {
+		// a concurrent refresh with the same token won the race
+		return nil, u.refreshReused(userId, sessionId, claims.Email, client)
+	}
+	session.RefreshTokenId = next
+	session.ExpiresAt = &expiresAt
+
+	u.recordEvent(model.LoginEvent{
+		UserId:     &userId,
+		Identifier: user.Email,
+		Event:      model.EventTokenRefresh,
+		SessionId:  &sessionId,
+	}, client)
+
+	return issueTokens(user, session)
+}
+
+func (u *userUsecase) refreshReused(userId, sessionId uuid.UUID, identifier string, client model.ClientInfo) error {
+	if err := u.sessions.RevokeSession(userId, sessionId); err != nil {
+		var detailed *fault.DetailedError
+		if !errors.As(err, &detailed) || detailed.Code != fault.ErrNotFound {
+			return err
+		}
+	}
+
+	u.recordEvent(model.LoginEvent{
+		UserId:     &userId,
+		Identifier: identifier,
+		Event:      model.EventTokenRefresh,
+		Reason:     model.ReasonRefreshTokenReused,
+		SessionId:  &sessionId,
+	}, client)
+
+	return fault.Custom(
+		http.StatusUnauthorized,
+		fault.ErrUnauthorized,
+		fmt.Sprintf("refresh token of session %s was already used; session revoked", sessionId),
+	)
+}
+
+func (u *userUsecase) ListSessions(claims *jwt.JWTPayload) ([]model.Session, error) {
+	userId, err := uuid.Parse(claims.UserId)
+	if err != nil {
+		return nil, fault.Custom(
+			http.StatusUnauthorized,
+			fault.ErrUnauthorized,
+			fmt.Sprintf("invalid user id in token: %q", claims.UserId),
+		)
+	}
+
+	sessions, err := u.sessions.ListSessions(userId)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range sessions {
+		sessions[i].Current = sessions[i].Id.String() == claims.SessionId
+	}
+	return sessions, nil
+}
+
+// RevokeSession ends one of the caller's sessions, including the current
+// one. Tokens of the session stop working on their next request.
+func (u *userUsecase) RevokeSession(claims *jwt.JWTPayload, id string, client model.ClientInfo) error {
+	sessionId, err := uuid.Parse(id)
+	if err != nil {
+		return fault.Custom(
+			http.StatusBadRequest,
+			fault.ErrBadRequest,
+			fmt.Sprintf("invalid session id: %q", id),
+		).WithDetails(fault.FieldError("id", "uuid", ""))
+	}
+
+	userId, err := uuid.Parse(claims.UserId)
+	if err != nil {
+		return fault.Custom(
+			http.StatusUnauthorized,
+			fault.ErrUnauthorized,
+			fmt.Sprintf("invalid user id in token: %q", claims.UserId),
+		)
+	}
+
+	if err := u.sessions.RevokeSession(userId, sessionId); err != nil {
+		return err
+	}
+
+	u.recordEvent(model.LoginEvent{
+		UserId:     &userId,
+		Identifier: claims.Email,
+		Event:      model.EventLogout,
+		SessionId:  &sessionId,
+	}, client)
+	return nil
+}
+
+// CheckSession rejects tokens whose session was revoked or has expired.
+func (u *userUsecase) CheckSession(claims *jwt.JWTPayload) error {
+	sessionId, err := uuid.Parse(claims.SessionId)
+	if err != nil {
+		return fault.Custom(
+			http.StatusUnauthorized,
+			fault.ErrUnauthorized,
+			fmt.Sprintf("invalid session id in token: %q", claims.SessionId),
+		)
+	}
+
+	session, err := u.liveSession(sessionId, claims.UserId)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if now.Sub(*session.LastUsedAt) >= sessionTouchInterval {
+		if err := u.sessions.TouchSession(sessionId, now); err != nil {
+			slog.Warn("failed to update session last use",
+				slog.String("session_id", sessionId.String()),
+				slog.Any("error", err),
+			)
+		}
+	}
+
+	return nil
+}
+
+// liveSession returns the session if it belongs to userId and is neither
+// revoked nor expired.
+func (u *userUsecase) liveSession(sessionId uuid.UUID, userId string) (*model.Session, error) {
+	session, err := u.sessions.GetSession(sessionId)
+	if err != nil {
+		var detailed *fault.DetailedError
+		if errors.As(err, &detailed) && detailed.Code == fault.ErrNotFound {
+			return nil, fault.Custom(
+				http.StatusUnauthorized,
+				fault.ErrUnauthorized,
+				fmt.Sprintf("session %s does not exist", sessionId),
+			)
+		}
+		return nil, err
+	}
+
+	if session.RevokedAt != nil || session.UserId.String() != userId || !session.ExpiresAt.After(time.Now()) {
+		return nil, fault.Custom(
+			http.StatusUnauthorized,
+			fault.ErrUnauthorized,
+			fmt.Sprintf("session %s is revoked or expired", sessionId),
+		)
+	}
+
+	return session, nil
+}
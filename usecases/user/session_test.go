@@ -0,0 +1,101 @@
+package usecases
+
+import (
+	"testing"
+
+	"github.com/Reza1878/goesclearning/user-service/helper/fault"
+	"github.com/Reza1878/goesclearning/user-service/helper/jwt"
+	"github.com/Reza1878/goesclearning/user-service/model"
+)
+
+func TestRefreshSession(t *testing.T) {
+	for _, b := range backends(t) {
+		t.Run(b.name, func(t *testing.T) {
+			u := b.new(t)
+			local := uniqueName("alice")
+
+			login, err := u.UserRegister(model.RegisterUser{Name: "Alice", Email: local + "@example.com", Password: "secret-1"}, testClient)
+			if err != nil {
+				t.Fatalf("register: %v", err)
+			}
+
+			if _, err := u.RefreshSession(login.AccessToken, testClient); errorCode(err) != fault.ErrUnauthorized {
+				t.Fatalf("refresh with an access token: got %v, want unauthorized", err)
+			}
+
+			refreshed, err := u.RefreshSession(login.RefreshToken, testClient)
+			if err != nil {
+				t.Fatalf("refresh: %v", err)
+			}
+			if refreshed.SessionId != login.SessionId {
+				t.Fatalf("refresh moved to session %s, want %s", refreshed.SessionId, login.SessionId)
+			}
+			if refreshed.RefreshToken == login.RefreshToken {
+				t.Fatalf("refresh token was not rotated")
+			}
+
+			access, err := jwt.GetClaims(refreshed.AccessToken)
+			if err != nil {
+				t.Fatalf("parse refreshed access token: %v", err)
+			}
+			if access.Type != jwt.TypeAccess {
+				t.Fatalf("refreshed access token has typ %q", access.Type)
+			}
+			if err := u.CheckSession(access); err != nil {
+				t.Fatalf("session after refresh: %v", err)
+			}
+
+			// replaying the rotated-out token revokes the whole session
+			if _, err := u.RefreshSession(login.RefreshToken, testClient); errorCode(err) != fault.ErrUnauthorized {
+				t.Fatalf("replayed refresh: got %v, want unauthorized", err)
+			}
+			if err := u.CheckSession(access); errorCode(err) != fault.ErrUnauthorized {
+				t.Fatalf("session after replay: got %v, want unauthorized", err)
+			}
+			if _, err := u.RefreshSession(refreshed.RefreshToken, testClient); errorCode(err) != fault.ErrUnauthorized {
+				t.Fatalf("refresh of a revoked session: got %v, want unauthorized", err)
+			}
+
+			claims := &jwt.JWTPayload{UserId: login.UserData.Id.String(), Email: login.UserData.Email}
+			events, err := u.ListSecurityEvents(claims, model.LoginEventQuery{Page: 1, Limit: 20, Event: model.EventTokenRefresh})
+			if err != nil {
+				t.Fatalf("ListSecurityEvents: %v", err)
+			}
+			var reused int
+			for _, event := range events.Items {
+				if event.Reason == model.ReasonRefreshTokenReused {
+					reused++
+				}
+			}
+			if len(events.Items) != 2 || reused != 1 {
+				t.Fatalf("token_refresh events = %+v, want one refresh and one reuse", events.Items)
+			}
+		})
+	}
+}
+
+func TestRefreshAfterRevoke(t *testing.T) {
+	for _, b := range backends(t) {
+		t.Run(b.name, func(t *testing.T) {
+			u := b.new(t)
+			local := uniqueName("alice")
+
+			login, err := u.UserRegister(model.RegisterUser{Name: "Alice", Email: local + "@example.com", Password: "secret-1"}, testClient)
+			if err != nil {
+				t.Fatalf("register: %v", err)
+			}
+
+			claims, err := jwt.GetClaims(login.AccessToken)
+			if err != nil {
+				t.Fatalf("parse access token: %v", err)
+			}
+			if err := u.RevokeSession(claims, login.SessionId, testClient); err != nil {
+				t.Fatalf("RevokeSession: %v", err)
+			}
+
+			if _, err := u.RefreshSession(login.RefreshToken, testClient); errorCode(err) != fault.ErrUnauthorized {
+				t.Fatalf("refresh after logout: got %v, want unauthorized", err)
+			}
+		})
+	}
+}
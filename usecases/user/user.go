@@ -1,155 +1,195 @@
 package usecases
 
 import (
-	"context"
+	"errors"
 	"fmt"
 	"net/http"
-	"time"
+	"strings"
 
-	"github.com/Reza1878/goesclearning/user-service/helper/cache"
+	"github.com/Reza1878/goesclearning/user-service/helper/email"
 	"github.com/Reza1878/goesclearning/user-service/helper/fault"
 	"github.com/Reza1878/goesclearning/user-service/helper/jwt"
+	"github.com/Reza1878/goesclearning/user-service/helper/username"
 	"github.com/Reza1878/goesclearning/user-service/middlewares"
 	"github.com/Reza1878/goesclearning/user-service/model"
+	eventRepo "github.com/Reza1878/goesclearning/user-service/repository/loginevent"
+	sessionRepo "github.com/Reza1878/goesclearning/user-service/repository/session"
 	repository "github.com/Reza1878/goesclearning/user-service/repository/user"
-	"github.com/google/uuid"
-	"github.com/redis/go-redis/v9"
 )
 
 type userUsecase struct {
-	user  repository.UserRepository
-	redis *redis.Client
+	user     repository.UserRepository
+	sessions sessionRepo.SessionRepository
+	events   eventRepo.LoginEventRepository
 }
 
-func NewUserUsecase(repository repository.UserRepository, redis *redis.Client) *userUsecase {
+func NewUserUsecase(repository repository.UserRepository, sessions sessionRepo.SessionRepository, events eventRepo.LoginEventRepository) *userUsecase {
 	return &userUsecase{
-		user:  repository,
-		redis: redis,
+		user:     repository,
+		sessions: sessions,
+		events:   events,
 	}
 }
 
 type UserUsecases interface {
-	UserRegister(body model.RegisterUser) (*model.LoginResponse, error)
-	UserLogin(body model.LoginRequest) (*model.LoginResponse, error)
+	UserRegister(body model.RegisterUser, client model.ClientInfo) (*model.LoginResponse, error)
+	UserLogin(body model.LoginRequest, client model.ClientInfo) (*model.LoginResponse, error)
+	RefreshSession(refreshToken string, client model.ClientInfo) (*model.LoginResponse, error)
+	UsernameAvailable(name string) (*model.UsernameAvailability, error)
+	ListSessions(claims *jwt.JWTPayload) ([]model.Session, error)
+	RevokeSession(claims *jwt.JWTPayload, id string, client model.ClientInfo) error
+	CheckSession(claims *jwt.JWTPayload) error
+	ListSecurityEvents(claims *jwt.JWTPayload, query model.LoginEventQuery) (*model.LoginEventListRes, error)
+	ListAllSecurityEvents(query model.LoginEventQuery) (*model.LoginEventListRes, error)
 }
 
-func (u *userUsecase) UserRegister(body model.RegisterUser) (*model.LoginResponse, error) {
-	ctx := context.TODO()
-	cacheKey := fmt.Sprintf("login:%s", body.Email)
-
-	if cacheExists, err := cache.Exist(ctx, u.redis, cacheKey); err != nil {
+// UserRegister creates an account and logs it in. An email that is
+// already registered, in any letter case, is a 409; the caller has to
+// log in with the password instead.
+func (u *userUsecase) UserRegister(body model.RegisterUser, client model.ClientInfo) (*model.LoginResponse, error) {
+	normalized, err := email.Normalize(body.Email)
+	if err != nil {
 		return nil, fault.Custom(
-			http.StatusInternalServerError,
-			fault.ErrInternalServer,
-			fmt.Sprintf("failed to check Redis key existence for '%s': %v", cacheKey, err),
-		)
-	} else if cacheExists {
-		tokenValue, err := cache.Get(ctx, u.redis, cacheKey)
-		if err != nil {
-			return nil, fault.Custom(
-				http.StatusInternalServerError,
-				fault.ErrInternalServer,
-				fmt.Sprintf("failed to retrieve access token from Redis for key '%s': %v", cacheKey, err),
-			)
-		}
+			http.StatusBadRequest,
+			fault.ErrBadRequest,
+			fmt.Sprintf("failed to register: %v", err),
+		).WithDetails(fault.FieldError("email", "email", ""))
+	}
 
-		accessToken, ok := tokenValue.(string)
-		if !ok {
-			return nil, fault.Custom(
-				http.StatusInternalServerError,
-				fault.ErrInternalServer,
-				fmt.Sprintf("cached access token is not a string for key '%s'", cacheKey),
-			)
-		}
+	body.Email = normalized
 
-		user, err := u.user.GetUserDetail(model.GetUserDetailRequest{Email: body.Email})
-		if err != nil {
-			return nil, err
+	if body.Username != "" {
+		body.Username = username.Normalize(body.Username)
+		if problem := username.Check(body.Username); problem != nil {
+			return nil, fault.Custom(
+				http.StatusBadRequest,
+				fault.ErrBadRequest,
+				fmt.Sprintf("failed to register: username %q breaks the %s rule", body.Username, problem.Rule),
+			).WithDetails(fault.FieldError("username", problem.Rule, problem.Param))
 		}
-
-		return &model.LoginResponse{
-			UserData:    *user,
-			AccessToken: accessToken,
-		}, nil
 	}
 
-	exist, err := u.user.UserExistsByName(body.Name)
+	body.Password = middlewares.GenerateHashed(body.Password)
 
+	userId, err := u.user.InsertUser(body)
 	if err != nil {
 		return nil, err
 	}
 
-	var user *model.User
-	var userId uuid.UUID
-
-	if !exist {
-		body.Password = middlewares.GenerateHashed(body.Password)
-
-		createdId, err := u.user.InsertUser(body)
-		if err != nil {
-			return nil, err
-		}
-		userId = *createdId
-
-		user, err = u.user.GetUserDetail(model.GetUserDetailRequest{
-			UserId: userId,
-		})
-		if err != nil {
-			return nil, err
-		}
-	} else {
-		user, err = u.user.GetUserDetail(model.GetUserDetailRequest{
-			Email: body.Email,
-		})
-		if err != nil {
-			return nil, err
-		}
-		userId = user.Id
+	user, err := u.user.GetUserDetail(model.GetUserDetailRequest{
+		UserId: *userId,
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	accessToken, payload, err := jwt.CreateAccessToken(user.Name, user.Email, userId.String())
+	return u.startSession(user, model.EventRegister, body.DeviceName, client)
+}
+
+func (u *userUsecase) UserLogin(body model.LoginRequest, client model.ClientInfo) (*model.LoginResponse, error) {
+	lookup, err := loginLookup(body)
 	if err != nil {
+		u.recordEvent(model.LoginEvent{
+			Identifier: body.Email,
+			Event:      model.EventLoginFailure,
+			Reason:     model.ReasonInvalidIdentifier,
+		}, client)
 		return nil, err
 	}
+	identifier := lookup.Email
+	if lookup.Username != "" {
+		identifier = lookup.Username
+	}
 
-	refreshToken, refreshPayload, err := jwt.CreateRefreshToken(user.Name, user.Email, userId.String())
+	user, err := u.user.GetUserDetail(lookup)
 	if err != nil {
+		var detailed *fault.DetailedError
+		if errors.As(err, &detailed) && detailed.Code == fault.ErrNotFound {
+			u.recordEvent(model.LoginEvent{
+				Identifier: identifier,
+				Event:      model.EventLoginFailure,
+				Reason:     model.ReasonUnknownAccount,
+			}, client)
+			return nil, fault.Custom(
+				http.StatusUnauthorized,
+				fault.ErrInvalidCredentials,
+				fmt.Sprintf("failed to login: no user with email '%s' or username '%s'", lookup.Email, lookup.Username),
+			)
+		}
 		return nil, err
 	}
 
-	if err := cache.Set(ctx, u.redis, cacheKey, *accessToken, 10*time.Minute); err != nil {
-		return nil, err
+	passwordMatch := middlewares.VerifyPassword(user.Password, body.Password)
+
+	if !passwordMatch {
+		u.recordEvent(model.LoginEvent{
+			UserId:     &user.Id,
+			Identifier: identifier,
+			Event:      model.EventLoginFailure,
+			Reason:     model.ReasonInvalidPassword,
+		}, client)
+		return nil, fault.Custom(
+			http.StatusUnauthorized,
+			fault.ErrInvalidCredentials,
+			fmt.Sprintf("failed to login: password mismatch for user %s", user.Id),
+		)
 	}
 
-	user.Password = ""
+	return u.startSession(user, model.EventLoginSuccess, body.DeviceName, client)
+}
 
-	return &model.LoginResponse{
-		UserData:              *user,
-		AccessToken:           *accessToken,
-		AccessTokenExpiresAt:  &payload.ExpiresAt.Time,
-		RefreshToken:          *refreshToken,
-		RefreshTokenExpiresAt: &refreshPayload.ExpiresAt.Time,
-	}, nil
+// loginLookup picks the account filter for a login. The email field may
+// also carry a username, for clients with a single login input.
+func loginLookup(body model.LoginRequest) (model.GetUserDetailRequest, error) {
+	if body.Username == "" && !strings.Contains(body.Email, "@") {
+		body.Username = body.Email
+	}
+	if body.Username != "" {
+		return model.GetUserDetailRequest{Username: username.Normalize(body.Username)}, nil
+	}
+
+	normalized, err := email.Normalize(body.Email)
+	if err != nil {
+		return model.GetUserDetailRequest{}, fault.Custom(
+			http.StatusUnauthorized,
+			fault.ErrInvalidCredentials,
+			fmt.Sprintf("failed to login: %v", err),
+		)
+	}
+	return model.GetUserDetailRequest{Email: normalized}, nil
 }
 
-func (u *userUsecase) UserLogin(body model.LoginRequest) (*model.LoginResponse, error) {
-	user, err := u.user.GetUserDetail(model.GetUserDetailRequest{Email: body.Email})
+func (u *userUsecase) UsernameAvailable(name string) (*model.UsernameAvailability, error) {
+	name = username.Normalize(name)
+	res := &model.UsernameAvailability{Username: name}
+
+	if problem := username.Check(name); problem != nil {
+		res.Reason = problem.Rule
+		return res, nil
+	}
+
+	taken, err := u.user.UsernameExists(name)
 	if err != nil {
 		return nil, err
 	}
+	if taken {
+		res.Reason = "taken"
+		return res, nil
+	}
 
-	passwordMatch := middlewares.VerifyPassword(user.Password, body.Password)
+	res.Available = true
+	return res, nil
+}
 
-	if !passwordMatch {
-		return nil, fault.Custom(http.StatusUnprocessableEntity, fault.ErrUnprocessable, fmt.Sprintf("failed to login: %v", err))
-	}
+func issueTokens(user *model.User, session *model.Session) (*model.LoginResponse, error) {
+	subject := tokenSubject(user, session)
 
-	accessToken, payload, err := jwt.CreateAccessToken(user.Name, user.Email, user.Id.String())
+	accessToken, payload, err := jwt.CreateAccessToken(subject)
 	if err != nil {
 		return nil, err
 	}
 
-	refreshToken, refreshPayload, err := jwt.CreateRefreshToken(user.Name, user.Email, user.Id.String())
+	refreshToken, refreshPayload, err := jwt.CreateRefreshToken(subject, session.RefreshTokenId)
 	if err != nil {
 		return nil, err
 	}
@@ -158,9 +198,21 @@ func (u *userUsecase) UserLogin(body model.LoginRequest) (*model.LoginResponse,
 
 	return &model.LoginResponse{
 		UserData:              *user,
+		SessionId:             session.Id.String(),
 		AccessToken:           *accessToken,
 		AccessTokenExpiresAt:  &payload.ExpiresAt.Time,
 		RefreshToken:          *refreshToken,
 		RefreshTokenExpiresAt: &refreshPayload.ExpiresAt.Time,
 	}, nil
 }
+
+func tokenSubject(user *model.User, session *model.Session) jwt.Subject {
+	return jwt.Subject{
+		Name:      user.Name,
+		Email:     user.Email,
+		UserId:    user.Id.String(),
+		Role:      user.Role,
+		SessionId: session.Id.String(),
+		FamilyId:  session.FamilyId.String(),
+	}
+}
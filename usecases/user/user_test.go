@@ -0,0 +1,287 @@
+package usecases
+
+import (
+	"database/sql"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Reza1878/goesclearning/user-service/helper/cache"
+	"github.com/Reza1878/goesclearning/user-service/helper/fault"
+	"github.com/Reza1878/goesclearning/user-service/helper/jwt"
+	"github.com/Reza1878/goesclearning/user-service/model"
+	eventRepo "github.com/Reza1878/goesclearning/user-service/repository/loginevent"
+	sessionRepo "github.com/Reza1878/goesclearning/user-service/repository/session"
+	repository "github.com/Reza1878/goesclearning/user-service/repository/user"
+	"github.com/google/uuid"
+	_ "github.com/lib/pq"
+)
+
+var testClient = model.ClientInfo{UserAgent: "go-test", IP: "192.0.2.1"}
+
+type backend struct {
+	name string
+	new  func(t *testing.T) *userUsecase
+}
+
+// backends runs every case against the in-memory repositories, through
+// the user cache in front of them, and also against Postgres when
+// DATABASE_URL points at a migrated database.
+func backends(t *testing.T) []backend {
+	t.Helper()
+	jwt.SetSigningKey([]byte("test-signing-key"))
+
+	list := []backend{{
+		name: "memory",
+		new: func(*testing.T) *userUsecase {
+			return NewUserUsecase(repository.NewMemoryStore(), sessionRepo.NewMemoryStore(), eventRepo.NewMemoryStore())
+		},
+	}, {
+		name: "cached",
+		new: func(t *testing.T) *userUsecase {
+			return NewUserUsecase(cachedUsers(t), sessionRepo.NewMemoryStore(), eventRepo.NewMemoryStore())
+		},
+	}}
+
+	url := os.Getenv("DATABASE_URL")
+	if url == "" {
+		return list
+	}
+
+	return append(list, backend{
+		name: "postgres",
+		new: func(t *testing.T) *userUsecase {
+			db, err := sql.Open("postgres", url)
+			if err != nil {
+				t.Fatalf("open postgres: %v", err)
+			}
+			t.Cleanup(func() { db.Close() })
+			if err := db.Ping(); err != nil {
+				t.Fatalf("ping postgres: %v", err)
+			}
+			return NewUserUsecase(repository.NewStore(db), sessionRepo.NewStore(db), eventRepo.NewStore(db))
+		},
+	})
+}
+
+// cachedUsers puts the user cache, on the in-memory cache backend, in
+// front of an in-memory repository.
+func cachedUsers(t *testing.T) repository.UserRepository {
+	t.Helper()
+
+	users, err := repository.NewCachedStore(repository.NewMemoryStore(), cache.NewMemory(1000), []byte(strings.Repeat("k", 32)), time.Minute, time.Minute)
+	if err != nil {
+		t.Fatalf("NewCachedStore: %v", err)
+	}
+	return users
+}
+
+// uniqueName keeps accounts from separate runs apart in a shared database.
+func uniqueName(prefix string) string {
+	return prefix + "_" + strings.ReplaceAll(uuid.NewString(), "-", "")[:12]
+}
+
+func errorCode(err error) fault.ErrorCode {
+	var detailed *fault.DetailedError
+	if errors.As(err, &detailed) {
+		return detailed.Code
+	}
+	return ""
+}
+
+func TestUserRegister(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing func(local string) *model.RegisterUser
+		register func(local string) model.RegisterUser
+		wantErr  fault.ErrorCode
+	}{
+		{
+			name: "new account",
+			register: func(local string) model.RegisterUser {
+				return model.RegisterUser{Name: "Alice", Email: " " + local + "@Example.COM ", Username: local, Password: "secret-1"}
+			},
+		},
+		{
+			name: "duplicate email in mixed case",
+			existing: func(local string) *model.RegisterUser {
+				return &model.RegisterUser{Name: "Alice", Email: local + "@example.com", Password: "secret-1"}
+			},
+			register: func(local string) model.RegisterUser {
+				return model.RegisterUser{Name: "Alice Again", Email: strings.ToUpper(local) + "@EXAMPLE.com", Password: "secret-2"}
+			},
+			wantErr: fault.ErrEmailAlreadyRegistered,
+		},
+		{
+			name: "taken username",
+			existing: func(local string) *model.RegisterUser {
+				return &model.RegisterUser{Name: "Alice", Email: local + "@example.com", Username: local, Password: "secret-1"}
+			},
+			register: func(local string) model.RegisterUser {
+				return model.RegisterUser{Name: "Bob", Email: local + "@example.org", Username: strings.ToUpper(local), Password: "secret-2"}
+			},
+			wantErr: fault.ErrUsernameTaken,
+		},
+		{
+			name: "invalid username",
+			register: func(local string) model.RegisterUser {
+				return model.RegisterUser{Name: "Alice", Email: local + "@example.com", Username: "admin", Password: "secret-1"}
+			},
+			wantErr: fault.ErrBadRequest,
+		},
+	}
+
+	for _, b := range backends(t) {
+		for _, tt := range tests {
+			t.Run(b.name+"/"+tt.name, func(t *testing.T) {
+				u := b.new(t)
+				local := uniqueName("alice")
+
+				if tt.existing != nil {
+					if _, err := u.UserRegister(*tt.existing(local), testClient); err != nil {
+						t.Fatalf("register existing account: %v", err)
+					}
+				}
+
+				res, err := u.UserRegister(tt.register(local), testClient)
+				if tt.wantErr != "" {
+					if code := errorCode(err); code != tt.wantErr {
+						t.Fatalf("got error %v (%s), want %s", err, code, tt.wantErr)
+					}
+					return
+				}
+				if err != nil {
+					t.Fatalf("UserRegister: %v", err)
+				}
+
+				if want := local + "@example.com"; res.UserData.Email != want {
+					t.Errorf("email = %q, want %q", res.UserData.Email, want)
+				}
+				if res.UserData.Password != "" {
+					t.Errorf("response leaks the password hash")
+				}
+				if res.AccessToken == "" || res.RefreshToken == "" || res.SessionId == "" {
+					t.Errorf("missing tokens or session in %+v", res)
+				}
+			})
+		}
+	}
+}
+
+func TestUserLogin(t *testing.T) {
+	tests := []struct {
+		name    string
+		login   func(local string) model.LoginRequest
+		wantErr fault.ErrorCode
+	}{
+		{
+			name: "by email",
+			login: func(local string) model.LoginRequest {
+				return model.LoginRequest{Email: local + "@example.com", Password: "secret-1"}
+			},
+		},
+		{
+			name: "by email in mixed case",
+			login: func(local string) model.LoginRequest {
+				return model.LoginRequest{Email: strings.ToUpper(local) + "@Example.com", Password: "secret-1"}
+			},
+		},
+		{
+			name: "by username",
+			login: func(local string) model.LoginRequest {
+				return model.LoginRequest{Username: local, Password: "secret-1"}
+			},
+		},
+		{
+			name: "by username in the email field",
+			login: func(local string) model.LoginRequest {
+				return model.LoginRequest{Email: strings.ToUpper(local), Password: "secret-1"}
+			},
+		},
+		{
+			name: "wrong password",
+			login: func(local string) model.LoginRequest {
+				return model.LoginRequest{Email: local + "@example.com", Password: "secret-2"}
+			},
+			wantErr: fault.ErrInvalidCredentials,
+		},
+		{
+			name: "unknown email",
+			login: func(local string) model.LoginRequest {
+				return model.LoginRequest{Email: local + "@example.org", Password: "secret-1"}
+			},
+			wantErr: fault.ErrInvalidCredentials,
+		},
+		{
+			name: "unknown username",
+			login: func(local string) model.LoginRequest {
+				return model.LoginRequest{Username: local + "_x", Password: "secret-1"}
+			},
+			wantErr: fault.ErrInvalidCredentials,
+		},
+	}
+
+	for _, b := range backends(t) {
+		for _, tt := range tests {
+			t.Run(b.name+"/"+tt.name, func(t *testing.T) {
+				u := b.new(t)
+				local := uniqueName("alice")
+
+				registered, err := u.UserRegister(model.RegisterUser{Name: "Alice", Email: local + "@example.com", Username: local, Password: "secret-1"}, testClient)
+				if err != nil {
+					t.Fatalf("register: %v", err)
+				}
+
+				res, err := u.UserLogin(tt.login(local), testClient)
+				if tt.wantErr != "" {
+					if code := errorCode(err); code != tt.wantErr {
+						t.Fatalf("got error %v (%s), want %s", err, code, tt.wantErr)
+					}
+					return
+				}
+				if err != nil {
+					t.Fatalf("UserLogin: %v", err)
+				}
+
+				if res.UserData.Id != registered.UserData.Id {
+					t.Errorf("logged in as %s, want %s", res.UserData.Id, registered.UserData.Id)
+				}
+				if res.SessionId == registered.SessionId {
+					t.Errorf("login reused the registration session %s", res.SessionId)
+				}
+			})
+		}
+	}
+}
+
+// TestUserRegisterAfterFailedLogin covers an account registered after a
+// login for its email missed: with the user cache the miss is cached,
+// and registering has to drop it.
+func TestUserRegisterAfterFailedLogin(t *testing.T) {
+	for _, b := range backends(t) {
+		t.Run(b.name, func(t *testing.T) {
+			u := b.new(t)
+			local := uniqueName("alice")
+			login := model.LoginRequest{Email: local + "@example.com", Password: "secret-1"}
+
+			if _, err := u.UserLogin(login, testClient); errorCode(err) != fault.ErrInvalidCredentials {
+				t.Fatalf("login before register: got %v, want %s", err, fault.ErrInvalidCredentials)
+			}
+
+			registered, err := u.UserRegister(model.RegisterUser{Name: "Alice", Email: local + "@example.com", Password: "secret-1"}, testClient)
+			if err != nil {
+				t.Fatalf("register: %v", err)
+			}
+
+			res, err := u.UserLogin(login, testClient)
+			if err != nil {
+				t.Fatalf("login after register: %v", err)
+			}
+			if res.UserData.Id != registered.UserData.Id {
+				t.Fatalf("logged in as %s, want %s", res.UserData.Id, registered.UserData.Id)
+			}
+		})
+	}
+}
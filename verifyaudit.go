@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/Reza1878/goesclearning/user-service/config"
+	"github.com/Reza1878/goesclearning/user-service/helper/audit"
+)
+
+// verifyAudit checks the audit log hash chain and reports whether it is
+// intact.
+func verifyAudit(w io.Writer, args []string) (bool, error) {
+	cfg, err := config.Load(args)
+	if errors.Is(err, config.ErrHelp) {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	db, err := config.InitPostgreSQL(cfg.Postgres)
+	if err != nil {
+		return false, err
+	}
+	defer db.Close()
+
+	report, err := audit.Verify(context.Background(), audit.NewPostgres(db))
+	if err != nil {
+		return false, err
+	}
+
+	if !report.OK() {
+		fmt.Fprintf(w, "audit log TAMPERED at entry %d: %s (%d entries verified before it)\n",
+			report.BrokenAt, report.Reason, report.Entries)
+		return false, nil
+	}
+
+	fmt.Fprintf(w, "audit log intact: %d entries, head %s\n", report.Entries, report.Head)
+	return true, nil
+}